@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// checkAccess exits with exitForbidden if a permission the validation cannot
+// run without is denied, and warns about the others.
+func checkAccess(ctx context.Context, v *validator.Validator, opts validator.Options) {
+	denied, err := v.CheckAccess(ctx)
+	if err != nil {
+		slog.Warn("Could not check permissions up front", "error", err)
+		return
+	}
+	missing := false
+	for _, permission := range denied {
+		where := "in namespace " + opts.Namespace
+		if permission.ClusterScoped {
+			where = "cluster wide"
+		}
+		if permission.Required {
+			slog.Error(fmt.Sprintf("Permission denied: you cannot %v %v", permission, where))
+			missing = true
+		} else {
+			slog.Warn(fmt.Sprintf("Permission denied: you cannot %v %v, the diagnosis will be incomplete", permission, where))
+		}
+	}
+	if missing {
+		exit(exitForbidden, "Missing permissions to validate in namespace %v.\n%v", opts.Namespace, roleHint(opts, ""))
+	}
+}
+
+// forbiddenPattern picks the user, verb and resource out of the message of a
+// 403, e.g. User "dev" cannot list resource "pods" in API group "" in the
+// namespace "apps".
+var forbiddenPattern = regexp.MustCompile(`User "([^"]*)" cannot (\S+) resource "([^"]*)"(?: in API group "([^"]*)")?`)
+
+// apiErrorMessage is the message of an API error, explaining a 403 as the
+// permission that is missing and the Role that grants it.
+func apiErrorMessage(err error, opts validator.Options) string {
+	if !apierrors.IsForbidden(err) {
+		return err.Error()
+	}
+	var status apierrors.APIStatus
+	user, action := "", "access the API"
+	if errors.As(err, &status) {
+		if match := forbiddenPattern.FindStringSubmatch(status.Status().Message); match != nil {
+			user, action = match[1], match[2]+" "+match[3]
+			if match[4] != "" {
+				action += "." + match[4]
+			}
+		}
+	}
+	where := "in namespace " + opts.Namespace
+	if opts.Namespace == "" {
+		where = "in all namespaces"
+	}
+	return fmt.Sprintf("%v\nYou lack permission to %v %v.\n%v", err, action, where, roleHint(opts, user))
+}
+
+// roleHint suggests the kubectl commands that grant the permissions the
+// validation uses, cluster wide when it runs in all namespaces.
+func roleHint(opts validator.Options, user string) string {
+	var resources, clusterResources []string
+	for _, permission := range validator.Permissions(opts) {
+		if permission.ClusterScoped && opts.Namespace != "" {
+			clusterResources = append(clusterResources, permission.ResourceName())
+		} else {
+			resources = append(resources, permission.ResourceName())
+		}
+	}
+	if user == "" {
+		user = "<user>"
+	}
+	role, binding, scope := "role", "rolebinding", " -n "+opts.Namespace
+	if opts.Namespace == "" {
+		role, binding, scope = "clusterrole", "clusterrolebinding", ""
+	}
+	hint := fmt.Sprintf("Ask a cluster admin to grant the permissions PodValidator uses, e.g.:\n  kubectl create %v podvalidator%v --verb=get,list,watch --resource=%v\n  kubectl create %v podvalidator%v --%v=podvalidator --user=%v",
+		role, scope, strings.Join(resources, ","), binding, scope, role, user)
+	if len(clusterResources) > 0 {
+		// Nodes and persistent volumes are only granted cluster wide.
+		hint += fmt.Sprintf("\n  kubectl create clusterrole podvalidator-nodes --verb=get,list --resource=%v\n  kubectl create clusterrolebinding podvalidator-nodes --clusterrole=podvalidator-nodes --user=%v",
+			strings.Join(clusterResources, ","), user)
+	}
+	return hint
+}
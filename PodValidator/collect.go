@@ -0,0 +1,130 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+)
+
+// bundleWriter writes the files of a support bundle, name being relative to
+// the bundle root.
+type bundleWriter interface {
+	write(name string, data []byte) error
+	close() error
+}
+
+type dirWriter string
+
+func (d dirWriter) write(name string, data []byte) error {
+	path := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (d dirWriter) close() error {
+	return nil
+}
+
+type tarWriter struct {
+	file *os.File
+	gzip *gzip.Writer
+	tar  *tar.Writer
+	root string
+	now  time.Time
+}
+
+func newTarWriter(path string) (*tarWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".tgz"), ".tar.gz")
+	gz := gzip.NewWriter(file)
+	return &tarWriter{file: file, gzip: gz, tar: tar.NewWriter(gz), root: root, now: time.Now()}, nil
+}
+
+func (t *tarWriter) write(name string, data []byte) error {
+	header := &tar.Header{Name: t.root + "/" + name, Mode: 0o644, Size: int64(len(data)), ModTime: t.now}
+	if err := t.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := t.tar.Write(data)
+	return err
+}
+
+func (t *tarWriter) close() error {
+	if err := t.tar.Close(); err != nil {
+		t.file.Close()
+		return err
+	}
+	if err := t.gzip.Close(); err != nil {
+		t.file.Close()
+		return err
+	}
+	return t.file.Close()
+}
+
+// collectBundle writes the result and, for every failing pod, its YAML,
+// events and full container logs to path: a directory, or a gzipped tarball
+// if path ends in .tar.gz or .tgz. The bundle is laid out as
+// result.json and <pod>/pod.yaml, <pod>/events.yaml, <pod>/logs/<container>.log.
+func collectBundle(ctx context.Context, v *validator.Validator, path string, result *validator.Result) error {
+	var writer bundleWriter = dirWriter(path)
+	if strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz") {
+		tw, err := newTarWriter(path)
+		if err != nil {
+			return err
+		}
+		writer = tw
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		writer.close()
+		return err
+	}
+	if err := writer.write("result.json", append(data, '\n')); err != nil {
+		writer.close()
+		return err
+	}
+	collected := 0
+	for _, pod := range result.Pods {
+		if pod.Ready || pod.Completed {
+			continue
+		}
+		artifacts, err := v.CollectArtifacts(ctx, pod.Name)
+		if err != nil {
+			slog.Warn("Error collecting pod", "pod", pod.Name, "error", err)
+			continue
+		}
+		for _, file := range artifacts.Files {
+			if err := writer.write(pod.Name+"/"+file.Name, file.Data); err != nil {
+				writer.close()
+				return err
+			}
+		}
+		if len(artifacts.Errors) > 0 {
+			if err := writer.write(pod.Name+"/errors.txt", []byte(strings.Join(artifacts.Errors, "\n")+"\n")); err != nil {
+				writer.close()
+				return err
+			}
+		}
+		collected++
+	}
+	if err := writer.close(); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Collected %v failing pods", collected), "path", path)
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+)
+
+// ANSI colors of the banners.
+const (
+	red    = "\033[31m"
+	yellow = "\033[33m"
+	green  = "\033[32m"
+	reset  = "\033[0m"
+)
+
+// colorOut enables colored banners in the report written to out.
+var colorOut bool
+
+// useColor reports whether output to file should be colored: only for
+// terminals, and never with -no-color or NO_COLOR (https://no-color.org).
+func useColor(noColor bool, file *os.File) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func colorize(enabled bool, color, text string) string {
+	if !enabled {
+		return text
+	}
+	return color + text + reset
+}
+
+// banner colors a banner of the report.
+func banner(color, text string) string {
+	return colorize(colorOut, color, text)
+}
+
+func levelColor(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return red
+	case level >= slog.LevelWarn:
+		return yellow
+	case level >= slog.LevelInfo:
+		return green
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// defaultConfigFile is read for flag defaults when -config is not given.
+const defaultConfigFile = ".podvalidator.yaml"
+
+// targetFlags name the workload to validate, the command line replaces all of
+// them in the config file if it gives any.
+var targetFlags = []string{"name", "deployment", "selector", "service", "from-stdin"}
+
+// namespaceFlags choose the namespace, the command line replaces all of them
+// in the config file if it gives a namespace.
+var namespaceFlags = []string{"namespace", "n", "namespace-from-context"}
+
+// loadConfigFile sets the flags named in the -config YAML file, or in
+// ~/.podvalidator.yaml if -config is not given, once the command line is
+// parsed. The flags skip reports true for, such as those given on the command
+// line, keep their value. The keys are flag names, e.g. "timeout: 2m" or
+// "log-match: [error, panic]".
+func loadConfigFile(args []string, skip func(name string) bool) error {
+	path, explicit := configPath(args)
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(home, defaultConfigFile)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	// Decoding the JSON form keeps numbers such as 10485760 from becoming
+	// floats printed as 1.048576e+07.
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return fmt.Errorf("invalid config file %v: %w", path, err)
+	}
+	var values map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return fmt.Errorf("invalid config file %v: %w", path, err)
+	}
+	for name, value := range values {
+		if name == "config" || flag.Lookup(name) == nil {
+			return fmt.Errorf("unknown flag %q in config file %v", name, path)
+		}
+		if skip(name) {
+			continue
+		}
+		if err := flag.Set(name, configValue(value)); err != nil {
+			return fmt.Errorf("invalid value for %v in config file %v: %w", name, path, err)
+		}
+	}
+	return nil
+}
+
+// configPath finds -config among the flags of args, which may follow the
+// positional arguments, explicit is false if it is not given.
+func configPath(args []string) (path string, explicit bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name != "config" {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			value = args[i+1]
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// configValue formats a config file value the way it is given on the
+// command line, lists as comma-separated values.
+func configValue(value interface{}) string {
+	if list, ok := value.([]interface{}); ok {
+		values := make([]string, len(list))
+		for i, item := range list {
+			values[i] = configValue(item)
+		}
+		return strings.Join(values, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// flagSet is a set of the flags given on the command line.
+type flagSet []*flag.Flag
+
+// setFlags returns the flags set so far, i.e. before the config file is
+// loaded those of the command line.
+func setFlags() flagSet {
+	var set flagSet
+	flag.Visit(func(f *flag.Flag) {
+		set = append(set, f)
+	})
+	return set
+}
+
+// has reports whether the flag or one of its aliases, such as -n for
+// -namespace, is in the set. Aliases share the variable they set.
+func (s flagSet) has(name string) bool {
+	lookup := flag.Lookup(name)
+	for _, f := range s {
+		if f.Name == name || lookup != nil && f.Value == lookup.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFlag(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFlagsConfigFileDefaults(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config, []byte("namespace: staging\nkind: statefulset\ndeployment: web\ntimeout: 2m\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name          string
+		args          []string
+		wantNamespace string
+		wantKind      string
+		wantName      string
+		wantSelector  string
+		wantKindSet   bool
+	}{
+		{name: "config defaults", args: []string{"-n", "apps"}, wantNamespace: "apps", wantKind: "statefulset", wantName: "web"},
+		{name: "service", args: []string{"-n", "apps", "-service", "api"}, wantNamespace: "apps", wantKind: "service", wantName: "api"},
+		{name: "selector", args: []string{"-n", "apps", "-selector", "app=api"}, wantNamespace: "apps", wantKind: "statefulset", wantSelector: "app=api"},
+		{name: "positional", args: []string{"apps", "api"}, wantNamespace: "apps", wantKind: "statefulset", wantName: "api"},
+		{name: "positional namespace", args: []string{"prod", "api"}, wantNamespace: "prod", wantKind: "statefulset", wantName: "api"},
+		{name: "config namespace", args: []string{"-selector", "app=api"}, wantNamespace: "staging", wantKind: "statefulset", wantSelector: "app=api"},
+		{name: "explicit kind", args: []string{"-n", "apps", "-kind", "daemonset"}, wantNamespace: "apps", wantKind: "daemonset", wantName: "web", wantKindSet: true},
+	}
+	args := os.Args
+	defer func() { os.Args, flag.CommandLine = args, flag.NewFlagSet(args[0], flag.ExitOnError) }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag.CommandLine = flag.NewFlagSet("PodValidator", flag.ExitOnError)
+			os.Args = append([]string{"PodValidator", "-config", config}, tt.args...)
+			opts := parseFlags()
+			if opts.Namespace != tt.wantNamespace {
+				t.Errorf("Namespace = %q, want %q", opts.Namespace, tt.wantNamespace)
+			}
+			if opts.Kind != tt.wantKind || opts.Name != tt.wantName || opts.Selector != tt.wantSelector || opts.kindSet != tt.wantKindSet {
+				t.Errorf("kind %q name %q selector %q kindSet %v, want %q %q %q %v", opts.Kind, opts.Name, opts.Selector, opts.kindSet, tt.wantKind, tt.wantName, tt.wantSelector, tt.wantKindSet)
+			}
+			if opts.Timeout.Minutes() != 2 {
+				t.Errorf("Timeout = %v, want the 2m of the config file", opts.Timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+)
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// formatJUnit renders the result as a JUnit testsuite with a testcase for the
+// workload and one for each of its pods, failing with the diagnosis of the
+// pods that are not ready.
+func formatJUnit(result *validator.Result, duration time.Duration) ([]byte, error) {
+	className := result.Namespace + "." + result.Name
+	suite := junitTestSuite{
+		Name:      fmt.Sprintf("%v %v/%v", result.Kind, result.Namespace, result.Name),
+		Time:      fmt.Sprintf("%.3f", duration.Seconds()),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	workload := junitTestCase{Name: fmt.Sprintf("%v %v", result.Kind, result.Name), ClassName: className}
+	if !result.Ready {
+		workload.Failure = &junitFailure{Message: fmt.Sprintf("%v %v is not ready", result.Kind, result.Name), Type: "NotReady", Text: strings.Join(result.Status, "\n")}
+	}
+	suite.TestCases = append(suite.TestCases, workload)
+
+	for _, pod := range result.Pods {
+		testCase := junitTestCase{Name: "Pod " + pod.Name, ClassName: className}
+		if !pod.Ready && !pod.Completed {
+			reasons := pod.Reasons()
+			message := fmt.Sprintf("Pod %v is %v and not ready", pod.Name, pod.Phase)
+			if len(reasons) > 0 {
+				message = reasons[0]
+			}
+			testCase.Failure = &junitFailure{Message: message, Type: "NotReady", Text: strings.Join(append(reasons, pod.Errors...), "\n")}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	for _, testCase := range suite.TestCases {
+		suite.Tests++
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+	}
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func writeJUnitFile(path string, result *validator.Result, duration time.Duration) error {
+	data, err := formatJUnit(result, duration)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// loadConfig builds the rest config and applies the -qps and -burst rate
+// limits and the -proxy-url to it.
+func loadConfig(opts options) (*rest.Config, error) {
+	config, err := restConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.qps > 0 {
+		config.QPS = float32(opts.qps)
+	}
+	if opts.burst > 0 {
+		config.Burst = opts.burst
+	}
+	// Without -proxy-url client-go uses the proxy-url of the kubeconfig
+	// cluster or else HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+	if opts.proxyURL != nil {
+		slog.Info("Using proxy", "proxy", opts.proxyURL.Redacted())
+		config.Proxy = http.ProxyURL(opts.proxyURL)
+	}
+	return config, nil
+}
+
+// restConfig builds the rest config from -server and -token, or else from the
+// kubeconfig files resolved by the standard loading rules (-kubeconfig, then
+// $KUBECONFIG, then ~/.kube/config), falling back to the in-cluster service
+// account when -in-cluster is set or none of those files exist.
+func restConfig(opts options) (*rest.Config, error) {
+	if opts.server != "" {
+		slog.Info("Using API server", "server", opts.server)
+		return &rest.Config{
+			Host:        opts.server,
+			BearerToken: opts.token,
+			TLSClientConfig: rest.TLSClientConfig{
+				Insecure: opts.insecure,
+				CAFile:   opts.caCert,
+			},
+		}, nil
+	}
+	if opts.inCluster {
+		slog.Info("Using in-cluster configuration")
+		return rest.InClusterConfig()
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = opts.kubeconfig
+
+	kubeConfigPaths := existingPaths(loadingRules)
+	if len(kubeConfigPaths) == 0 {
+		searched := strings.Join(loadingRules.GetLoadingPrecedence(), string(os.PathListSeparator))
+		slog.Info("No kubeconfig found, using in-cluster configuration", "searched", searched)
+		config, err := rest.InClusterConfig()
+		if errors.Is(err, rest.ErrNotInCluster) {
+			return nil, fmt.Errorf("no kubeconfig found in %v and not running in a cluster, set -kubeconfig or -server and -token", searched)
+		}
+		return config, err
+	}
+	slog.Info("Using kubeconfig", "path", strings.Join(kubeConfigPaths, string(os.PathListSeparator)))
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: opts.context}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+	if opts.context != "" {
+		if err := checkContext(clientConfig, opts.context); err != nil {
+			return nil, err
+		}
+		slog.Info("Using context", "context", opts.context)
+	}
+	return clientConfig.ClientConfig()
+}
+
+// serviceAccountNamespace holds the namespace of the pod's service account
+// in a cluster.
+const serviceAccountNamespace = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// contextNamespace is the namespace of the kubeconfig context in use or, with
+// -server or in a cluster, of the service account. It is "default" if none is
+// set, as for kubectl.
+func contextNamespace(opts options) (string, error) {
+	if opts.server == "" && !opts.inCluster {
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		loadingRules.ExplicitPath = opts.kubeconfig
+		if len(existingPaths(loadingRules)) > 0 {
+			overrides := &clientcmd.ConfigOverrides{CurrentContext: opts.context}
+			namespace, _, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).Namespace()
+			return namespace, err
+		}
+	}
+	if data, err := os.ReadFile(serviceAccountNamespace); err == nil && strings.TrimSpace(string(data)) != "" {
+		return strings.TrimSpace(string(data)), nil
+	}
+	return metav1.NamespaceDefault, nil
+}
+
+func checkContext(clientConfig clientcmd.ClientConfig, contextName string) error {
+	rawConfig, err := clientConfig.RawConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := rawConfig.Contexts[contextName]; ok {
+		return nil
+	}
+	available := make([]string, 0, len(rawConfig.Contexts))
+	for name := range rawConfig.Contexts {
+		available = append(available, name)
+	}
+	sort.Strings(available)
+	return fmt.Errorf("context %q not found in kubeconfig, available contexts: %s", contextName, strings.Join(available, ", "))
+}
+
+// existingPaths returns the kubeconfig files from the loading rules that are
+// present on disk. An explicit -kubeconfig is always returned so that a typo
+// surfaces as an error instead of a silent in-cluster fallback.
+func existingPaths(loadingRules *clientcmd.ClientConfigLoadingRules) []string {
+	if loadingRules.ExplicitPath != "" {
+		return []string{loadingRules.ExplicitPath}
+	}
+	var paths []string
+	for _, path := range loadingRules.GetLoadingPrecedence() {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+func newLogger(w io.Writer, format string, level slog.Level, color bool) *slog.Logger {
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+	}
+	return slog.New(&bannerHandler{w: w, level: level, color: color, mu: &sync.Mutex{}})
+}
+
+// bannerHandler renders records as the "[WARN] message key=value" lines the
+// tool has always printed, rather than slog's key=value text format.
+type bannerHandler struct {
+	w     io.Writer
+	level slog.Level
+	color bool
+	attrs []slog.Attr
+	mu    *sync.Mutex
+}
+
+func (h *bannerHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *bannerHandler) Handle(_ context.Context, record slog.Record) error {
+	var line strings.Builder
+	fmt.Fprintf(&line, "%v %v", colorize(h.color, levelColor(record.Level), "["+record.Level.String()+"]"), record.Message)
+	for _, attr := range h.attrs {
+		fmt.Fprintf(&line, " %v", attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		fmt.Fprintf(&line, " %v", attr)
+		return true
+	})
+	line.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line.String())
+	return err
+}
+
+func (h *bannerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &handler
+}
+
+// WithGroup is not needed by this tool, groups are flattened into the line.
+func (h *bannerHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
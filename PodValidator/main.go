@@ -1,174 +1,546 @@
 package main
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"text/template"
 	"time"
 
-	Appsv1 "k8s.io/api/apps/v1"
-	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
+// Exit codes, documented in the usage output so CI pipelines can branch on them.
+const (
+	exitSuccess   = 0
+	exitNotReady  = 1
+	exitUsage     = 2
+	exitAPIError  = 3
+	exitForbidden = 4
+	exitPaused    = 5
+)
+
+// diagnosisTimeout is the time allowed for diagnosing pods once the wait for
+// the workload has run out.
+const diagnosisTimeout = 2 * time.Minute
+
+func exit(code int, format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(code)
+}
+
+func usageError(format string, args ...interface{}) {
+	fmt.Fprintf(flag.CommandLine.Output(), "error: "+format+"\n\n", args...)
+	flag.Usage()
+	os.Exit(exitUsage)
+}
+
+// apiExitCode maps an API error to the exit code for permission problems or
+// to the generic API/connection error code.
+func apiExitCode(err error) int {
+	if apierrors.IsForbidden(err) {
+		return exitForbidden
+	}
+	return exitAPIError
+}
+
 func getClientWithoutWarnings(config *rest.Config) (*kubernetes.Clientset, error) {
 	config = rest.CopyConfig(config)
 	config.WarningHandler = rest.NoWarnings{}
 	return kubernetes.NewForConfig(config)
 }
 
-func main() {
-	args := os.Args
-	namespace := args[1]
-	deploymentName := args[2]
+// getDynamicClientWithoutWarnings returns the client for the kinds without a
+// typed client, such as deploymentconfig.
+func getDynamicClientWithoutWarnings(config *rest.Config) (*dynamic.DynamicClient, error) {
+	config = rest.CopyConfig(config)
+	config.WarningHandler = rest.NoWarnings{}
+	return dynamic.NewForConfig(config)
+}
 
-	userHomeDir, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Printf("error getting user home dir: %v\n", err)
-		os.Exit(1)
-	}
-	kubeConfigPath := filepath.Join(userHomeDir, ".kube", "config")
-	fmt.Printf("Using kubeconfig: %s\n", kubeConfigPath)
+type options struct {
+	validator.Options
+	kubeconfig    string
+	context       string
+	inCluster     bool
+	server        string
+	token         string
+	caCert        string
+	insecure      bool
+	proxyURL      *url.URL
+	output        string
+	logLevel      slog.Level
+	logFormat     string
+	webhookURL    string
+	notifyOK      bool
+	manifest      string
+	probe         bool
+	serviceProbe  validator.ServiceProbe
+	noColor       bool
+	metrics       string
+	pushURL       string
+	junit         string
+	snapshot      string
+	collect       string
+	fromStdin     bool
+	allNS         bool
+	nsFromContext bool
+	quiet         bool
+	explain       bool
+	serve         string
+	gvr           string
+	service       string
+	skipAccess    bool
+	onlyFailing   bool
+	verbose       bool
+	timings       bool
+	template      string
+	resource      validator.CustomResource
+	qps           float64
+	burst         int
+	kindSet       bool
+}
 
-	kubeConfig, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
-	if err != nil {
-		fmt.Printf("error getting Kubernetes config: %v\n", err)
-		os.Exit(1)
+// commandName is the name the tool is run as, "kubectl validate-pods" when it
+// is installed as the kubectl plugin kubectl-validate_pods.
+func commandName() string {
+	name := filepath.Base(os.Args[0])
+	if plugin, ok := strings.CutPrefix(name, "kubectl-"); ok {
+		return "kubectl " + strings.ReplaceAll(plugin, "_", "-")
 	}
+	return name
+}
 
-	clientset, err := getClientWithoutWarnings(kubeConfig)
-	if err != nil {
-		log.Fatalf("Error creating Kubernetes client: %v", err)
-	}
+func usage() {
+	name := commandName()
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %s -namespace <namespace> -deployment <deployment> [flags]\n  %s -namespace <namespace> -kind <kind> -name <name> [flags]\n  %s -namespace <namespace> -gvr <group/version/resource> -name <name> [flags]\n  %s -namespace <namespace> -selector <selector> [flags]\n  %s -namespace <namespace> -service <service> [flags]\n  %s -all-namespaces -selector <selector> [-kind <kind>] [flags]\n  %s <namespace> <deployment>\n  %s -from-stdin [-namespace <namespace>] [flags] < deployment.yaml\n  %s -manifest <file|-> [flags]\n  %s -serve <address> [flags]\n\nFlags:\n", name, name, name, name, name, name, name, name, name, name)
+	flag.PrintDefaults()
+	fmt.Fprintf(flag.CommandLine.Output(), `
+Exit codes:
+  %d  deployment is available
+  %d  deployment is not ready
+  %d  invalid arguments
+  %d  Kubernetes API or connection error
+  %d  permission denied by the Kubernetes API (RBAC)
+  %d  deployment is paused
+`, exitSuccess, exitNotReady, exitUsage, exitAPIError, exitForbidden, exitPaused)
+}
 
-	deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.TODO(), deploymentName, metav1.GetOptions{})
-	if err != nil {
-		log.Fatalf("Error getting deployment: %v", err)
+func parseFlags() options {
+	var opts options
+	flag.StringVar(&opts.Namespace, "namespace", "", "namespace of the workload (required), all for every namespace")
+	flag.StringVar(&opts.Namespace, "n", "", "shorthand for -namespace, as in kubectl")
+	flag.BoolVar(&opts.nsFromContext, "namespace-from-context", false, "without -namespace, use the namespace of the kubeconfig context (default \"default\"), as kubectl does")
+	flag.BoolVar(&opts.allNS, "all-namespaces", false, "validate the pods, or the workloads of an explicit -kind, matching -selector in every namespace")
+	flag.StringVar(&opts.Kind, "kind", "deployment", "kind of workload to validate: "+strings.Join(validator.SupportedKinds(), ", "))
+	flag.StringVar(&opts.Name, "name", "", "name of the workload to validate")
+	flag.StringVar(&opts.Name, "deployment", "", "name of the deployment to validate, same as -name (required unless -name or -selector is set)")
+	flag.StringVar(&opts.service, "service", "", "validate the pods behind this Service's selector and whether its endpoints are ready, same as -kind service -name")
+	flag.StringVar(&opts.gvr, "gvr", "", "validate the -name workload of this group/version/resource, e.g. argoproj.io/v1alpha1/rollouts, instead of a -kind")
+	flag.StringVar(&opts.resource.ReadyPath, "ready-jsonpath", `{.status.conditions[?(@.type=="Ready")].status}`, "JSONPath of the readiness field of a -gvr workload")
+	flag.StringVar(&opts.resource.ReadyValue, "ready-value", "True", "value of -ready-jsonpath once a -gvr workload is ready")
+	flag.StringVar(&opts.Selector, "selector", "", "validate the pods matching this label selector instead of a workload")
+	flag.BoolVar(&opts.fromStdin, "from-stdin", false, "validate the pods selected by the Deployment manifest read from stdin, e.g. kubectl get -o yaml output, without getting the Deployment from the cluster")
+	flag.StringVar(&opts.kubeconfig, "kubeconfig", "", "path to the kubeconfig file (default $KUBECONFIG or ~/.kube/config)")
+	flag.StringVar(&opts.context, "context", "", "kubeconfig context to use (default current-context)")
+	flag.BoolVar(&opts.inCluster, "in-cluster", false, "use the in-cluster service account configuration instead of a kubeconfig")
+	flag.StringVar(&opts.server, "server", "", "URL of the Kubernetes API server to connect to with -token instead of a kubeconfig")
+	flag.StringVar(&opts.token, "token", "", "bearer token, e.g. of a service account, to authenticate to -server with")
+	flag.StringVar(&opts.caCert, "ca-cert", "", "file with the CA certificate of -server (default the system roots)")
+	flag.BoolVar(&opts.insecure, "insecure-skip-tls-verify", false, "do not verify the certificate of -server, insecure")
+	proxyURL := flag.String("proxy-url", "", "HTTP(S) or SOCKS5 proxy to reach the API server through, e.g. http://proxy.example.com:3128 (default the kubeconfig proxy-url or $HTTPS_PROXY, honoring $NO_PROXY)")
+	flag.BoolVar(&opts.skipAccess, "skip-access-check", false, "skip checking the RBAC permissions the validation needs before it starts")
+	flag.Float64Var(&opts.qps, "qps", 0, "maximum queries per second to the Kubernetes API (default the client-go limit of 5)")
+	flag.IntVar(&opts.burst, "burst", 0, "maximum burst of queries to the Kubernetes API (default the client-go limit of 10)")
+	waitCondition := flag.String("wait-for-condition", "", "wait for this condition Type=Status of the deployment, e.g. Available=True or a condition set by a custom controller, instead of a complete rollout as kubectl rollout status does")
+	flag.Int64Var(&opts.Revision, "revision", 0, "require the deployment to have rolled out this revision (deployment.kubernetes.io/revision), not just any revision")
+	flag.DurationVar(&opts.Timeout, "timeout", 60*time.Second, "total time to wait for the deployment to become available")
+	flag.DurationVar(&opts.Interval, "interval", 10*time.Second, "time to wait between status checks")
+	flag.BoolVar(&opts.Watch, "watch", false, "watch the workload for status changes instead of polling every -interval")
+	flag.BoolVar(&opts.FollowEvents, "follow-events", false, "print Warning events of the pods as they occur while waiting")
+	flag.StringVar(&opts.output, "output", "text", "output format: text, wide (text with a table of the pods), json or yaml (the json fields as YAML)")
+	flag.StringVar(&opts.template, "template", "", "print the result with this Go text/template instead of text or json, e.g. '{{.Name}} {{.Ready}}{{range .Pods}} {{.Name}}{{end}}'")
+	flag.TextVar(&opts.logLevel, "log-level", slog.LevelInfo, "minimum level of progress messages: debug, info, warn or error")
+	flag.StringVar(&opts.logFormat, "log-format", "text", "format of progress messages: text or json")
+	logMatch := flag.String("log-match", "error", "comma-separated keywords or regular expressions a log line must match (case-insensitive)")
+	flag.IntVar(&opts.Logs.MaxLines, "log-lines", 10, "maximum number of matching log lines to report per container")
+	flag.IntVar(&opts.Logs.Context, "log-context", 0, "number of log lines to print before and after each match")
+	flag.Int64Var(&opts.Logs.TailLines, "log-tail", 0, "only scan the last N lines of each container log (0 scans the whole log)")
+	flag.DurationVar(&opts.Logs.Since, "log-since", 5*time.Minute, "only scan the container log lines written within this duration (0 scans the whole log)")
+	flag.Int64Var(&opts.Logs.MaxBytes, "log-max-bytes", 10<<20, "stop reading each container log after this many bytes (0 reads the whole log)")
+	restartThreshold := flag.Int("restart-threshold", 3, "warn about running and ready containers that restarted more than this many times (0 never warns)")
+	flag.BoolVar(&opts.FailFast, "fail-fast", false, "stop diagnosing at the first failing pod, in name order, and only report that one, for a quick reason instead of a complete diagnosis")
+	flag.IntVar(&opts.MaxConcurrentPods, "max-concurrent-pods", 10, "maximum number of pods diagnosed, and their logs fetched, at once")
+	flag.DurationVar(&opts.Logs.Follow, "follow-logs", 0, "follow the log of each failing container for up to this long, e.g. 2m, printing matching lines as they arrive, to catch the error of its next crash (0 does not follow)")
+	flag.DurationVar(&opts.Logs.Timeout, "timeout-per-pod-log", 30*time.Second, "maximum time to fetch the logs of each container (0 waits as long as the whole run)")
+	containers := flag.String("containers", "", "comma-separated names of the containers to diagnose and fetch logs of, e.g. to leave out sidecars, which still count for the readiness of the pod (default all containers)")
+	logExclude := flag.String("log-exclude", "datadog", "comma-separated keywords or regular expressions that exclude a matching log line (case-insensitive)")
+	flag.StringVar(&opts.webhookURL, "webhook-url", "", "Slack incoming webhook or generic endpoint to POST a JSON summary to when validation fails")
+	flag.BoolVar(&opts.notifyOK, "notify-on-success", false, "also POST to -webhook-url when validation succeeds")
+	flag.StringVar(&opts.metrics, "metrics-file", "", "write Prometheus metrics about the validation to this file, e.g. for the node_exporter textfile collector")
+	flag.StringVar(&opts.pushURL, "pushgateway-url", "", "push Prometheus metrics about the validation to this Pushgateway")
+	flag.StringVar(&opts.junit, "junit-file", "", "write the validation result to this file as a JUnit XML report for CI test dashboards")
+	flag.StringVar(&opts.snapshot, "compare-to-previous", "", "report the image, replica and failing container changes since the last successful validation recorded in this JSON file, and record this one if it succeeds")
+	flag.StringVar(&opts.collect, "collect-dir", "", "write the result and the YAML, events and full container logs of each failing pod to this directory, or to a gzipped tarball if it ends in .tar.gz or .tgz")
+	flag.BoolVar(&opts.quiet, "quiet", false, "only print the final verdict and, on failure, the diagnosis; errors still go to stderr")
+	flag.BoolVar(&opts.onlyFailing, "only-failing", false, "only print the failing pods and containers, leaving out the healthy ones")
+	flag.BoolVar(&opts.verbose, "verbose", false, "print the raw state of each failing container as JSON instead of its reason and message")
+	flag.BoolVar(&opts.verbose, "v", false, "shorthand for -verbose")
+	flag.BoolVar(&opts.timings, "timings", false, "print how long each phase took, such as loading the config, getting the workload, listing pods and fetching the logs of each pod")
+	flag.BoolVar(&opts.explain, "explain", false, "explain the cause of each diagnosed reason and the steps to fix it")
+	flag.BoolVar(&opts.noColor, "no-color", false, "disable colored output, also disabled by NO_COLOR or when not writing to a terminal")
+	flag.BoolVar(&opts.probe, "probe-service", false, "once the workload is ready, send an HTTP GET to the Service selecting its pods and fail unless it answers with 2xx or 3xx")
+	flag.StringVar(&opts.serviceProbe.Path, "probe-path", "/", "path requested by -probe-service")
+	flag.StringVar(&opts.serviceProbe.Port, "probe-port", "", "name or number of the Service port requested by -probe-service (default its first port)")
+	flag.StringVar(&opts.serve, "serve", "", "serve validations over HTTP on this address, e.g. :8080, with POST /validate taking {\"namespace\", \"deployment\"} and GET /healthz")
+	flag.StringVar(&opts.manifest, "manifest", "", "statically check the workloads in this YAML file (- for stdin) instead of validating a deployed workload")
+	flag.String("config", "", "YAML file of flag defaults, e.g. \"timeout: 2m\", overridden by the command line (default ~/"+defaultConfigFile+" if it exists)")
+	flag.Usage = usage
+	args := parseArgs(os.Args[1:])
+	// Only the flags given on the command line count as explicit, those of
+	// the config file are defaults.
+	explicit := setFlags()
+	// A workload named on the command line, also positionally, replaces the
+	// one of the config file instead of conflicting with it.
+	target := len(args) > 1 || len(args) == 1 && (explicit.has("namespace") || explicit.has("namespace-from-context"))
+	for _, name := range targetFlags {
+		target = target || explicit.has(name)
+	}
+	// Likewise a namespace given on the command line, also as the first of
+	// two positional arguments, replaces the one of the config file.
+	namespace := len(args) > 1
+	for _, name := range namespaceFlags {
+		namespace = namespace || explicit.has(name)
+	}
+	if err := loadConfigFile(os.Args[1:], func(name string) bool {
+		return explicit.has(name) || target && containsFlag(targetFlags, name) || namespace && containsFlag(namespaceFlags, name)
+	}); err != nil {
+		usageError("%v", err)
+	}
+	opts.kindSet = explicit.has("kind")
+	if opts.service != "" {
+		if opts.kindSet || explicit.has("name") || explicit.has("selector") || explicit.has("gvr") || explicit.has("from-stdin") {
+			usageError("-service cannot be combined with -kind, -deployment, -name, -selector, -gvr or -from-stdin")
+		}
 	}
 
-	labelSelector := metav1.FormatLabelSelector(deployment.Spec.Selector)
-
-	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{
-		LabelSelector: labelSelector,
-	})
-	if err != nil {
-		log.Fatalf("Error getting pod: %v", err)
+	// The positional form "<namespace> <deployment>" is still accepted for
+	// backward compatibility, and is how kubectl plugins are usually run.
+	// With -namespace-from-context a single argument is the deployment.
+	if opts.Namespace == "" && len(args) > 0 && !(opts.nsFromContext && len(args) == 1) {
+		opts.Namespace, args = args[0], args[1:]
+	}
+	if opts.Name == "" && opts.Selector == "" && len(args) > 0 {
+		opts.Name = args[0]
+	}
+	if opts.service != "" {
+		opts.Kind, opts.Name = "service", opts.service
+	}
 
+	if opts.fromStdin {
+		if opts.Name != "" || opts.Selector != "" || opts.gvr != "" || opts.allNS || opts.manifest != "" || opts.serve != "" {
+			usageError("-from-stdin cannot be combined with -deployment, -name, -selector, -gvr, -all-namespaces, -manifest or -serve")
+		}
+		deployment, selector, err := validator.ReadDeployment(os.Stdin)
+		if err != nil {
+			usageError("invalid -from-stdin manifest: %v", err)
+		}
+		opts.Selector = selector
+		if opts.Namespace == "" {
+			opts.Namespace = deployment.Namespace
+		}
+	}
+	if opts.Namespace == "all" {
+		opts.Namespace, opts.allNS = "", true
+	}
+	if opts.allNS {
+		if opts.Selector == "" || opts.Name != "" {
+			usageError("-all-namespaces requires -selector and cannot be combined with -deployment or -name")
+		}
+		if opts.Kind == "deploymentconfig" {
+			usageError("-kind deploymentconfig cannot be combined with -all-namespaces")
+		}
+	} else if opts.manifest != "" {
+		if opts.Name != "" || opts.Selector != "" {
+			usageError("-manifest cannot be combined with -deployment, -name or -selector")
+		}
+	} else if opts.serve != "" {
+		if opts.Namespace != "" || opts.Name != "" || opts.Selector != "" {
+			usageError("-serve takes the namespace and workload from each request and cannot be combined with -namespace, -deployment, -name or -selector")
+		}
+	} else if opts.Namespace == "" && !opts.nsFromContext {
+		usageError("-namespace is required, or -namespace-from-context to use the namespace of the kubeconfig context")
+	} else if opts.Name == "" && opts.Selector == "" {
+		usageError("-deployment (or -name) or -selector is required")
+	}
+	if opts.Name != "" && opts.Selector != "" {
+		usageError("-selector cannot be combined with -deployment or -name")
+	}
+	if opts.gvr != "" {
+		if opts.Name == "" || opts.allNS || opts.manifest != "" || opts.serve != "" {
+			usageError("-gvr requires -name and cannot be combined with -selector, -all-namespaces, -manifest or -serve")
+		}
+		var err error
+		if opts.resource.GVR, err = validator.ParseGVR(opts.gvr); err != nil {
+			usageError("invalid -gvr: %v", err)
+		}
+		if err := validator.ParseReadyPath(opts.resource.ReadyPath); err != nil {
+			usageError("invalid -ready-jsonpath: %v", err)
+		}
+		opts.Resource = &opts.resource
+	}
+	opts.Kind = strings.ToLower(opts.Kind)
+	if !isSupportedKind(opts.Kind) {
+		usageError("unsupported -kind %q, must be one of: %v", opts.Kind, strings.Join(validator.SupportedKinds(), ", "))
+	}
+	var err error
+	if *waitCondition != "" {
+		if opts.Kind != "deployment" || opts.Selector != "" || opts.Resource != nil {
+			usageError("-wait-for-condition requires -kind deployment and -deployment or -name")
+		}
+		if opts.Condition, err = validator.ParseCondition(*waitCondition); err != nil {
+			usageError("invalid -wait-for-condition: %v", err)
+		}
+	}
+	if opts.Revision < 0 || opts.Revision > 0 && (opts.Kind != "deployment" || opts.Selector != "" || opts.Resource != nil) {
+		usageError("-revision must be positive and requires -kind deployment and -deployment or -name")
+	}
+	if opts.snapshot != "" && (opts.allNS || opts.manifest != "" || opts.serve != "") {
+		usageError("-compare-to-previous cannot be combined with -all-namespaces, -manifest or -serve")
+	}
+	if opts.collect != "" && (opts.allNS || opts.manifest != "" || opts.serve != "") {
+		usageError("-collect-dir cannot be combined with -all-namespaces, -manifest or -serve")
+	}
+	if opts.Interval <= 0 || opts.Timeout < 0 {
+		usageError("-interval must be positive and -timeout must not be negative")
+	}
+	if opts.MaxConcurrentPods <= 0 {
+		usageError("-max-concurrent-pods must be positive")
+	}
+	if *restartThreshold < 0 {
+		usageError("-restart-threshold must not be negative")
 	}
+	opts.RestartThreshold = int32(*restartThreshold)
+	if opts.server != "" {
+		if opts.token == "" || opts.kubeconfig != "" || opts.context != "" || opts.inCluster {
+			usageError("-server requires -token and cannot be combined with -kubeconfig, -context or -in-cluster")
+		}
+		if opts.insecure && opts.caCert != "" {
+			usageError("-ca-cert cannot be combined with -insecure-skip-tls-verify")
+		}
+	} else if opts.token != "" || opts.caCert != "" || opts.insecure {
+		usageError("-token, -ca-cert and -insecure-skip-tls-verify require -server")
+	}
+	if *proxyURL != "" {
+		if opts.proxyURL, err = url.Parse(*proxyURL); err != nil || opts.proxyURL.Host == "" {
+			usageError("invalid -proxy-url %q, must be a URL like http://proxy.example.com:3128", *proxyURL)
+		}
+		switch opts.proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			usageError("unsupported -proxy-url scheme %q, must be http, https or socks5", opts.proxyURL.Scheme)
+		}
+	}
+	if opts.qps < 0 || opts.burst < 0 {
+		usageError("-qps and -burst must not be negative")
+	}
+	if opts.output != "text" && opts.output != "wide" && opts.output != "json" && opts.output != "yaml" {
+		usageError("unsupported -output %q, must be text, wide, json or yaml", opts.output)
+	}
+	if opts.logFormat != "text" && opts.logFormat != "json" {
+		usageError("unsupported -log-format %q, must be text or json", opts.logFormat)
+	}
+	if opts.Logs.MaxLines <= 0 || opts.Logs.Context < 0 || opts.Logs.TailLines < 0 || opts.Logs.Since < 0 || opts.Logs.MaxBytes < 0 || opts.Logs.Timeout < 0 || opts.Logs.Follow < 0 {
+		usageError("-log-lines must be positive, -log-context, -log-tail, -log-since, -log-max-bytes, -timeout-per-pod-log and -follow-logs must not be negative")
+	}
+	if opts.probe {
+		opts.ServiceProbe = &opts.serviceProbe
+	}
+	if opts.template != "" {
+		if reportTemplate, err = template.New("template").Funcs(templateFuncs).Parse(opts.template); err != nil {
+			usageError("invalid -template: %v", err)
+		}
+	}
+	for _, name := range strings.Split(*containers, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts.Containers = append(opts.Containers, name)
+		}
+	}
+	if opts.Logs.Match, err = validator.CompilePatterns(*logMatch); err != nil {
+		usageError("invalid -log-match: %v", err)
+	}
+	if opts.Logs.Exclude, err = validator.CompilePatterns(*logExclude); err != nil {
+		usageError("invalid -log-exclude: %v", err)
+	}
+	return opts
+}
 
-	count := 6
-	for count > 0 {
-		if printDeploymentStatus(deployment) {
-			fmt.Printf("\n\n\n------------------------------------------\n[INFO] Deployment Status [%v]:\n------------------------------------------\n", deploymentName)
-			fmt.Printf("Deployment successfull.\n\n")
-			count = 0
-		} else if count == 1 {
-			fmt.Printf("\n[ERROR] Deployment is not up yet, checking pod logs \n")
-			printPodStatus(pods, clientset, namespace)
-			fmt.Printf("\n\n\n------------------------------------------\n[Error] Deployment Status [%v]:\n------------------------------------------\n", deploymentName)
-			log.Fatalf("Deployment failed.\n\n")
-		} else {
-			fmt.Printf("[WARN] Deployment is not up yet, trying again in 60 secs... \n")
-			time.Sleep(2 * time.Second)
-			count = count - 1
+// parseArgs parses the flags in args and returns the positional arguments.
+// Unlike flag.Parse flags may follow the positional arguments, as in
+// "kubectl validate-pods apps web --context prod", which kubectl passes to
+// the plugin as they were given. Everything after "--" is positional.
+func parseArgs(args []string) []string {
+	var positional []string
+	for {
+		flag.CommandLine.Parse(args)
+		rest := flag.Args()
+		if consumed := args[:len(args)-len(rest)]; len(consumed) > 0 && consumed[len(consumed)-1] == "--" {
+			return append(positional, rest...)
 		}
+		if len(rest) == 0 {
+			return positional
+		}
+		positional, args = append(positional, rest[0]), rest[1:]
 	}
 }
 
-func printDeploymentStatus(deployment *Appsv1.Deployment) bool {
-	for _, condition := range deployment.Status.Conditions {
-		if condition.Type == "Available" && condition.Status == "True" {
+func isSupportedKind(kind string) bool {
+	for _, supported := range validator.SupportedKinds() {
+		if kind == supported {
 			return true
 		}
 	}
 	return false
 }
 
-func getPodlogs(podName string, container v1.ContainerStatus, namespace string, clientset *kubernetes.Clientset) {
-	fmt.Printf("Conatiner[%v]:", container.Name)
-	logOptions := &v1.PodLogOptions{
-		Container: container.Name,
+func main() {
+	opts := parseFlags()
+	// Progress messages share stdout with the text report, but move to
+	// stderr when stdout carries the json or yaml report or only the verdict.
+	logOutput := os.Stdout
+	if opts.output == "json" || opts.output == "yaml" || opts.template != "" {
+		out = io.Discard
+		logOutput = os.Stderr
+	}
+	if opts.quiet {
+		logOutput = os.Stderr
+	}
+	colorOut = useColor(opts.noColor, os.Stdout)
+	quiet = opts.quiet
+	explain = opts.explain
+	wide = opts.output == "wide"
+	onlyFailing = opts.onlyFailing
+	verbose = opts.verbose
+	if opts.quiet && opts.logLevel < slog.LevelError {
+		opts.logLevel = slog.LevelError
+	}
+	slog.SetDefault(newLogger(logOutput, opts.logFormat, opts.logLevel, useColor(opts.noColor, logOutput)))
+	if opts.fromStdin {
+		slog.Info("Validating the pods of the Deployment read from stdin", "selector", opts.Selector)
+	}
+
+	if opts.manifest != "" {
+		checkManifest(opts)
+		return
 	}
-	status, _ := json.MarshalIndent(container.State, "", "  ")
-	fmt.Println(string(status))
 
-	req := clientset.CoreV1().Pods(namespace).GetLogs(podName, logOptions)
-	podLogs, err := req.Stream(context.TODO())
+	loading := time.Now()
+	kubeConfig, err := loadConfig(opts)
 	if err != nil {
-		fmt.Printf("Error getting logs: %v", err)
+		exit(exitAPIError, "error getting Kubernetes config: %v", err)
 	}
-	defer podLogs.Close()
-	reader := bufio.NewReader(podLogs)
-	lineCount := 0
-	seenLines := make(map[string]bool)
-	fmt.Printf("\n\n[NOTE] Reason for Error:\n\n")
-	for {
-		line, _, err := reader.ReadLine()
-		if err != nil {
-			if err.Error() == "EOF" {
-				break
-			}
-			fmt.Printf("Error reading logs: %v", err)
-		}
-		lineStr := string(line)
-		if strings.Contains(strings.ToLower(string(line)), "error") && !strings.Contains(strings.ToLower(string(line)), "datadog") {
-			if !seenLines[lineStr] {
-
-				fmt.Println(lineStr)
-				seenLines[lineStr] = true
-				lineCount++
-				if lineCount >= 10 {
-					break
-				}
-			}
+	timings := []validator.Timing{{Phase: "load config", Count: 1, Duration: time.Since(loading)}}
+	if opts.Namespace == "" && opts.nsFromContext && !opts.allNS && opts.serve == "" {
+		if opts.Namespace, err = contextNamespace(opts); err != nil {
+			exit(exitAPIError, "error getting the namespace of the kubeconfig context: %v", err)
 		}
+		slog.Info("Using namespace of the context", "namespace", opts.Namespace)
+	}
+
+	clientset, err := getClientWithoutWarnings(kubeConfig)
+	if err != nil {
+		exit(exitAPIError, "Error creating Kubernetes client: %v", err)
+	}
+	dynamicClient, err := getDynamicClientWithoutWarnings(kubeConfig)
+	if err != nil {
+		exit(exitAPIError, "Error creating Kubernetes client: %v", err)
 	}
-}
 
-func printPodStatus(pods *v1.PodList, clientset *kubernetes.Clientset, namespace string) {
-
-	for _, pod := range pods.Items {
-		fmt.Printf("\n-------------------------------------------------\nPod status [%v]:\n-------------------------------------------------\n\n", pod.Name)
-		for _, container := range pod.Status.ContainerStatuses {
-			if container.State.Running == nil || !container.Ready {
-				if container.State.Waiting != nil {
-					if container.State.Waiting.Reason == "ImagePullBackOff" || container.State.Waiting.Reason == "ErrImagePull" {
-						status, _ := json.MarshalIndent(container.State, "", "  ")
-						fmt.Println(string(status))
-						secretName := pod.Spec.ImagePullSecrets[0].Name
-						_, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), secretName, metav1.GetOptions{})
-						if err != nil {
-							fmt.Printf("\n\n[NOTE] Reason for ImagePullBackOff: Error getting secret %v: %v in namspace %v, please add them\n\n", secretName, err, namespace)
-						} else {
-							fmt.Printf("\n\n[NOTE] Reason for ImagePullBackOff:	Secret %v is present in namespace %v, this error could be due to expired or wrong values in the secret\n\n", secretName, namespace)
-						}
-					} else if container.State.Waiting.Reason == "CreateContainerConfigError" {
-						status, _ := json.MarshalIndent(container.State, "", "  ")
-						fmt.Println(string(status))
-						if strings.Contains(container.State.Waiting.Message, "secret") {
-							msg := "Check if the env block in deployment yaml has correct \"secretKeyRef\", also see the \"SecretStore\" if the secret is from vault"
-							fmt.Printf("\n\n[NOTE] Reason for CreateContainerConfigError: %v\n", msg)
-						} else {
-							msg := "Check if the env block in deployment yaml has correct \"configMapKeyRef\" to the volume mount"
-							fmt.Printf("\n\n[NOTE] Reason for CreateContainerConfigError: %v\n", msg)
-						}
-					} else {
-						getPodlogs(pod.Name, container, namespace, clientset)
-					}
-				} else {
-					getPodlogs(pod.Name, container, namespace, clientset)
-				}
-			} else {
-				fmt.Printf("Container %v is in running state\n", container.Name)
+	// Bound every API call by the wait timeout plus time for diagnosis, and
+	// cancel in-flight requests on Ctrl-C.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if opts.allNS {
+		sweep(ctx, clientset, opts)
+		return
+	}
+	if opts.serve != "" {
+		serve(ctx, clientset, dynamicClient, opts)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout+diagnosisTimeout)
+	defer cancel()
+
+	v := validator.New(clientset, opts.Options)
+	v.Dynamic = dynamicClient
+	printTimings := func() {
+		if opts.timings {
+			writeTimings(append(timings, v.Timings()...), time.Since(loading))
+		}
+	}
+	if !opts.skipAccess {
+		checkAccess(ctx, v, opts.Options)
+	}
+	start := time.Now()
+	result, err := v.Validate(ctx)
+	if err != nil {
+		printTimings()
+		if ctx.Err() != nil {
+			exit(exitNotReady, "Validation aborted: %v", err)
+		}
+		exit(apiExitCode(err), "%v", apiErrorMessage(err, opts.Options))
+	}
+
+	printResult(result)
+	if opts.snapshot != "" {
+		compareToPrevious(opts.snapshot, result)
+	}
+	writeReport(opts.output, result)
+	if opts.junit != "" {
+		if err := writeJUnitFile(opts.junit, result, time.Since(start)); err != nil {
+			slog.Warn("Error writing JUnit report", "error", err)
+		}
+	}
+	if opts.collect != "" && !result.Ready {
+		if err := collectBundle(ctx, v, opts.collect, result); err != nil {
+			slog.Warn("Error writing the collected pods", "path", opts.collect, "error", err)
+		}
+	}
+	if opts.metrics != "" || opts.pushURL != "" {
+		metrics := formatMetrics(result, time.Since(start))
+		if opts.metrics != "" {
+			if err := writeMetricsFile(opts.metrics, metrics); err != nil {
+				slog.Warn("Error writing metrics file", "error", err)
+			}
+		}
+		if opts.pushURL != "" {
+			if err := pushMetrics(ctx, opts.pushURL, result, metrics); err != nil {
+				slog.Warn("Error pushing metrics", "error", err)
 			}
 		}
 	}
+	if opts.webhookURL != "" && (!result.Ready || opts.notifyOK) {
+		if err := notify(ctx, opts.webhookURL, result); err != nil {
+			slog.Warn("Error sending webhook notification", "error", err)
+		}
+	}
+	printTimings()
+	if result.Paused {
+		exit(exitPaused, "%v is paused, resume it with: kubectl rollout resume deployment/%v -n %v", result.Kind, result.Name, result.Namespace)
+	}
+	if !result.Ready {
+		exit(exitNotReady, "%v failed: %v", result.Kind, result.Summary)
+	}
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+	"k8s.io/client-go/kubernetes"
+)
+
+// checkManifest runs the static checks of -manifest. Secrets and config maps
+// are also looked up in the cluster when a kubeconfig is available.
+func checkManifest(opts options) {
+	input := io.Reader(os.Stdin)
+	if opts.manifest != "-" {
+		file, err := os.Open(opts.manifest)
+		if err != nil {
+			exit(exitUsage, "error reading manifest: %v", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var client kubernetes.Interface
+	if kubeConfig, err := loadConfig(opts); err != nil {
+		slog.Info("No cluster available, only checking the manifest itself", "error", err)
+	} else if clientset, err := getClientWithoutWarnings(kubeConfig); err != nil {
+		slog.Info("No cluster available, only checking the manifest itself", "error", err)
+	} else {
+		client = clientset
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, diagnosisTimeout)
+	defer cancel()
+
+	results, err := validator.New(client, opts.Options).CheckManifest(ctx, input)
+	if err != nil {
+		exit(exitUsage, "%v", err)
+	}
+
+	printManifestResults(results)
+	writeReport(opts.output, results)
+	for _, result := range results {
+		if len(result.Findings) > 0 {
+			exit(exitNotReady, "Manifest check failed.")
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+)
+
+// formatMetrics renders the outcome of a validation as gauges in the
+// Prometheus text exposition format.
+func formatMetrics(result *validator.Result, duration time.Duration) []byte {
+	labels := fmt.Sprintf(`namespace="%v",kind="%v",name="%v"`, escapeLabel(result.Namespace), escapeLabel(result.Kind), escapeLabel(result.Name))
+	ready := 0
+	if result.Ready {
+		ready = 1
+	}
+	// The summary counts every pod, result.Pods only the diagnosed ones.
+	notReady := result.Summary.Failing
+
+	var buf bytes.Buffer
+	gauge := func(name, help string, value interface{}) {
+		fmt.Fprintf(&buf, "# HELP %v %v\n# TYPE %v gauge\n%v{%v} %v\n", name, help, name, name, labels, value)
+	}
+	gauge("podvalidator_deployment_ready", "Whether the workload became ready (1) or not (0).", ready)
+	gauge("podvalidator_pods_not_ready", "Number of pods of the workload that were not ready.", notReady)
+	gauge("podvalidator_validation_duration_seconds", "Time taken by the validation.", duration.Seconds())
+	gauge("podvalidator_last_run_timestamp_seconds", "Unix time the validation finished.", time.Now().Unix())
+	return buf.Bytes()
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// writeMetricsFile replaces path atomically so that a node_exporter textfile
+// collector never reads a partially written file.
+func writeMetricsFile(path string, metrics []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	// CreateTemp makes the file 0600, node_exporter often runs as another user.
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(metrics); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// pushMetrics replaces the metrics of this workload's group on a Pushgateway.
+func pushMetrics(ctx context.Context, gateway string, result *validator.Result, metrics []byte) error {
+	endpoint := fmt.Sprintf("%v/metrics/job/podvalidator/namespace/%v/name/%v", strings.TrimSuffix(gateway, "/"), url.PathEscape(result.Namespace), url.PathEscape(result.Name))
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(metrics))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("pushgateway returned %v", resp.Status)
+	}
+	return nil
+}
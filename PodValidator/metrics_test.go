@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+)
+
+func TestFormatMetricsCountsNotReadyPodsFromSummary(t *testing.T) {
+	result := &validator.Result{
+		Namespace: "apps", Kind: "Job", Name: "migrate",
+		Summary: validator.Summary{Total: 4, Completed: 1, Failing: 3},
+		// With -fail-fast only the first failing pod is diagnosed.
+		Pods: []validator.PodResult{{Name: "migrate-2"}, {Name: "migrate-1", Completed: true}},
+	}
+	metrics := string(formatMetrics(result, time.Second))
+	if want := `podvalidator_pods_not_ready{namespace="apps",kind="Job",name="migrate"} 3`; !strings.Contains(metrics, want) {
+		t.Errorf("metrics = %q, want them to contain %q", metrics, want)
+	}
+}
+
+func TestWriteMetricsFileIsWorldReadable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "podvalidator.prom")
+	if err := writeMetricsFile(path, []byte("podvalidator_up 1\n")); err != nil {
+		t.Fatalf("writeMetricsFile() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0o644 {
+		t.Errorf("mode = %v, want %v", got, os.FileMode(0o644))
+	}
+}
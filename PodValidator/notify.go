@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+)
+
+// notification is posted to -webhook-url. Text makes it render in Slack
+// incoming webhooks, the other fields are for generic endpoints.
+type notification struct {
+	Text        string       `json:"text"`
+	Namespace   string       `json:"namespace"`
+	Kind        string       `json:"kind"`
+	Name        string       `json:"name"`
+	Ready       bool         `json:"ready"`
+	FailingPods []failingPod `json:"failingPods,omitempty"`
+}
+
+type failingPod struct {
+	Name    string   `json:"name"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+func newNotification(result *validator.Result) notification {
+	n := notification{Namespace: result.Namespace, Kind: result.Kind, Name: result.Name, Ready: result.Ready}
+	for _, pod := range result.Pods {
+		if !pod.Ready && !pod.Completed {
+			n.FailingPods = append(n.FailingPods, failingPod{Name: pod.Name, Reasons: pod.Reasons()})
+		}
+	}
+
+	var text strings.Builder
+	if result.Ready {
+		fmt.Fprintf(&text, "PodValidator: %v %v/%v is available", result.Kind, result.Namespace, result.Name)
+	} else {
+		fmt.Fprintf(&text, "PodValidator: %v %v/%v failed, %v failing pods", result.Kind, result.Namespace, result.Name, len(n.FailingPods))
+	}
+	for _, pod := range n.FailingPods {
+		fmt.Fprintf(&text, "\n• %v", pod.Name)
+		for _, reason := range pod.Reasons {
+			fmt.Fprintf(&text, "\n    %v", reason)
+		}
+	}
+	n.Text = text.String()
+	return n
+}
+
+func notify(ctx context.Context, url string, result *validator.Result) error {
+	body, err := json.Marshal(newNotification(result))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %v", resp.Status)
+	}
+	return nil
+}
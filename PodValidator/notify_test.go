@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+)
+
+func TestNewNotificationSkipsCompletedPods(t *testing.T) {
+	result := &validator.Result{
+		Namespace: "apps", Kind: "Job", Name: "migrate",
+		Pods: []validator.PodResult{
+			{Name: "migrate-1", Completed: true},
+			{Name: "migrate-2"},
+		},
+	}
+	n := newNotification(result)
+	if len(n.FailingPods) != 1 || n.FailingPods[0].Name != "migrate-2" {
+		t.Errorf("FailingPods = %+v, want only migrate-2", n.FailingPods)
+	}
+}
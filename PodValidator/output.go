@@ -0,0 +1,419 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// out receives the human readable progress and diagnosis text. It is
+// discarded in json and yaml mode so stdout only carries the report.
+var out io.Writer = os.Stdout
+
+// quiet reduces the report to the verdict and, on failure, the diagnosis.
+var quiet bool
+
+// explain adds the cause and remediation steps of each diagnosed reason.
+var explain bool
+
+// wide adds a table of the diagnosed pods ahead of their details.
+var wide bool
+
+// onlyFailing leaves the healthy pods and containers out of the diagnosis.
+var onlyFailing bool
+
+// verbose prints the raw state of failing containers as JSON instead of a
+// one line summary.
+var verbose bool
+
+func printResult(result *validator.Result) {
+	if quiet {
+		for _, pod := range result.Pods {
+			printPod(result, pod)
+		}
+		if result.Ready {
+			fmt.Fprintf(out, "%v %v/%v successfull.\n", result.Kind, result.Namespace, result.Name)
+		} else if result.Paused {
+			fmt.Fprintf(out, "%v %v/%v is paused: %v.\n", result.Kind, result.Namespace, result.Name, result.Summary)
+		} else {
+			fmt.Fprintf(out, "%v %v/%v failed: %v.\n", result.Kind, result.Namespace, result.Name, result.Summary)
+		}
+		return
+	}
+	if result.Paused {
+		fmt.Fprintf(out, "\n%v %v is paused, not waiting for it to roll out, checking pod logs \n", banner(yellow, "[NOTE]"), result.Kind)
+	} else if result.Service != nil && !result.Service.Healthy() {
+		fmt.Fprintf(out, "\n%v %v is up but its Service is not serving requests\n", banner(red, "[ERROR]"), result.Kind)
+	} else if !result.Ready {
+		fmt.Fprintf(out, "\n%v %v is not up yet, checking pod logs \n", banner(red, "[ERROR]"), result.Kind)
+	}
+	for _, status := range result.Status {
+		fmt.Fprintln(out, status)
+	}
+	fmt.Fprintf(out, "Summary: %v\n", result.Summary)
+	printImages(result)
+	for _, flapping := range result.Flapping {
+		last := ""
+		if flapping.LastReason != "" {
+			last = fmt.Sprintf(", last terminated with %v (exit code %v)", flapping.LastReason, flapping.LastExitCode)
+		}
+		fmt.Fprintf(out, "%v Container %v of pod %v is running but restarted %v times%v, it may be flapping\n", banner(yellow, "[NOTE]"), flapping.Container, flapping.Pod, flapping.RestartCount, last)
+	}
+	if wide && len(result.Pods) > 0 {
+		fmt.Fprintln(out)
+		printPodTable(result.Pods)
+	}
+	for _, pod := range result.Pods {
+		printPod(result, pod)
+	}
+	for _, node := range result.Nodes {
+		if len(node.Pods) > 1 {
+			fmt.Fprintf(out, "\n%v %v failing pods run on unhealthy node %v (%v): %v\n", banner(yellow, "[NOTE]"), len(node.Pods), node.Name, strings.Join(node.Conditions, "; "), strings.Join(node.Pods, ", "))
+		}
+	}
+
+	if result.Ready {
+		fmt.Fprintf(out, "\n\n\n------------------------------------------\n%v %v Status [%v]:\n------------------------------------------\n", banner(green, "[INFO]"), result.Kind, result.Name)
+		fmt.Fprintf(out, "%v\n\n", banner(green, result.Kind+" successfull."))
+	} else {
+		fmt.Fprintf(out, "\n\n\n------------------------------------------\n%v %v Status [%v]:\n------------------------------------------\n", banner(red, "[Error]"), result.Kind, result.Name)
+	}
+}
+
+func printPod(result *validator.Result, pod validator.PodResult) {
+	if onlyFailing && (pod.Ready || pod.Completed) {
+		return
+	}
+	fmt.Fprintf(out, "\n-------------------------------------------------\nPod status [%v]:\n-------------------------------------------------\n\n", pod.Name)
+	if pod.Completed {
+		fmt.Fprintf(out, "Pod %v completed successfully, not diagnosed\n", pod.Name)
+		return
+	}
+	for _, err := range pod.Errors {
+		fmt.Fprintf(out, "Error diagnosing pod: %v\n", err)
+	}
+	if len(pod.Events) > 0 {
+		fmt.Fprintf(out, "[NOTE] Warning events:\n")
+		for _, event := range pod.Events {
+			fmt.Fprintf(out, "  %v (x%v): %v\n", event.Reason, event.Count, event.Message)
+		}
+		fmt.Fprintln(out)
+	}
+	explained := map[string]bool{}
+	for _, diagnosis := range pod.Diagnosis {
+		fmt.Fprintf(out, "[NOTE] Reason for %v: %v\n\n", diagnosis.Reason, diagnosis.Message)
+		printExplanation(explained, diagnosis.Reason, result.Namespace, pod.Name, "")
+	}
+	for _, container := range pod.Containers {
+		if container.Ready && container.State.Running != nil {
+			if onlyFailing {
+				continue
+			}
+			fmt.Fprintf(out, "Container %v is in running state\n", container.Name)
+			printImage(container)
+			continue
+		}
+		printImage(container)
+		printResources(container)
+		group := result.ErrorGroup(pod.Name, container.Name)
+		if group != nil && group.Pods[0] != pod.Name {
+			fmt.Fprintf(out, "Container %v has the same error as in pod %v, see above\n", container.Name, group.Pods[0])
+			continue
+		}
+		status := " " + stateSummary(container.State)
+		if verbose {
+			raw, _ := json.MarshalIndent(container.State, "", "  ")
+			status = string(raw)
+		}
+		if container.Sidecar {
+			fmt.Fprintf(out, "Sidecar container[%v]:%v\n", container.Name, status)
+		} else if container.Init {
+			fmt.Fprintf(out, "Init container[%v]:%v\n", container.Name, status)
+		} else {
+			fmt.Fprintf(out, "Conatiner[%v]:%v\n", container.Name, status)
+		}
+		printTermination(container)
+		for _, diagnosis := range container.Diagnosis {
+			fmt.Fprintf(out, "\n\n[NOTE] Reason for %v: %v\n\n", diagnosis.Reason, diagnosis.Message)
+			printExplanation(explained, diagnosis.Reason, result.Namespace, pod.Name, container.Name)
+		}
+		printExplanation(explained, container.Reason, result.Namespace, pod.Name, container.Name)
+		if container.Logs != nil {
+			printLogs(container.Logs)
+		}
+		if group != nil {
+			fmt.Fprintf(out, "\n%v %v pods share this error: %v\n", banner(yellow, "[NOTE]"), len(group.Pods), strings.Join(group.Pods, ", "))
+		}
+	}
+}
+
+// printImages prints the images the containers run, and warns when the
+// replicas of a container run different images.
+func printImages(result *validator.Result) {
+	if len(result.Images) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "Images:")
+	for _, version := range result.Images {
+		fmt.Fprintf(out, "  %v: %v (%v) in %v pods\n", version.Container, version.Image, validator.ImageDigest(version.ImageID), len(version.Pods))
+	}
+	for _, container := range result.MixedImages() {
+		var versions []string
+		for _, version := range result.Images {
+			if version.Container == container {
+				versions = append(versions, fmt.Sprintf("%v in %v", validator.ImageDigest(version.ImageID), strings.Join(version.Pods, ", ")))
+			}
+		}
+		fmt.Fprintf(out, "%v Container %v runs different images across replicas, the rollout may be incomplete: %v\n", banner(yellow, "[NOTE]"), container, strings.Join(versions, "; "))
+	}
+}
+
+func printImage(container validator.ContainerResult) {
+	if container.Image == "" {
+		return
+	}
+	if container.ImageID == "" {
+		fmt.Fprintf(out, "  Image: %v (not pulled)\n", container.Image)
+		return
+	}
+	fmt.Fprintf(out, "  Image: %v (%v)\n", container.Image, validator.ImageDigest(container.ImageID))
+}
+
+// printExplanation prints the cause and remediation steps of reason once per
+// pod and container when -explain is set.
+func printExplanation(explained map[string]bool, reason, namespace, pod, container string) {
+	if !explain || explained[container+"/"+reason] {
+		return
+	}
+	explanation, ok := validator.Explain(reason, namespace, pod, container)
+	if !ok {
+		return
+	}
+	explained[container+"/"+reason] = true
+	fmt.Fprintf(out, "%v %v: %v\n", banner(yellow, "[EXPLAIN]"), reason, explanation.Cause)
+	for i, step := range explanation.Steps {
+		fmt.Fprintf(out, "  %v. %v\n", i+1, step)
+	}
+	fmt.Fprintln(out)
+}
+
+// printPodTable prints the pods as columns in the style of kubectl get pods.
+func printPodTable(pods []validator.PodResult) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPHASE\tREADY\tRESTARTS\tREASON")
+	for _, pod := range pods {
+		if onlyFailing && (pod.Ready || pod.Completed) {
+			continue
+		}
+		ready, total, restarts := 0, 0, int32(0)
+		for _, container := range pod.Containers {
+			restarts += container.RestartCount
+			if container.Init {
+				continue
+			}
+			total++
+			if container.Ready {
+				ready++
+			}
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v/%v\t%v\t%v\n", pod.Name, pod.Phase, ready, total, restarts, worstReason(pod))
+	}
+	w.Flush()
+}
+
+// worstReason is the reason of the pod's own diagnosis, such as Unschedulable,
+// or else of its first failing container, init containers first.
+func worstReason(pod validator.PodResult) string {
+	if pod.Completed {
+		return "Completed"
+	}
+	if len(pod.Diagnosis) > 0 {
+		return pod.Diagnosis[0].Reason
+	}
+	for _, container := range pod.Containers {
+		if container.Reason != "" {
+			return container.Reason
+		}
+		if len(container.Diagnosis) > 0 {
+			return container.Diagnosis[0].Reason
+		}
+		if terminated := container.State.Terminated; terminated != nil && terminated.Reason != "" && terminated.Reason != "Completed" {
+			return terminated.Reason
+		}
+	}
+	return "-"
+}
+
+// stateSummary is the reason and message of a container state, the part of
+// the raw state that matters for most failures.
+func stateSummary(state v1.ContainerState) string {
+	var summary, message string
+	switch {
+	case state.Waiting != nil:
+		summary, message = "waiting", state.Waiting.Message
+		if state.Waiting.Reason != "" {
+			summary += " (" + state.Waiting.Reason + ")"
+		}
+	case state.Terminated != nil:
+		summary, message = "terminated", state.Terminated.Message
+		if state.Terminated.Reason != "" {
+			summary += " (" + state.Terminated.Reason + ")"
+		}
+	case state.Running != nil:
+		summary = "running but not ready"
+		if !state.Running.StartedAt.IsZero() {
+			summary += ", started at " + state.Running.StartedAt.UTC().Format(time.RFC3339)
+		}
+	default:
+		return "no state reported yet"
+	}
+	if message != "" {
+		summary += ": " + strings.TrimSpace(message)
+	}
+	return summary
+}
+
+func printTermination(container validator.ContainerResult) {
+	termination := container.Termination
+	if termination == nil {
+		return
+	}
+	when := "exited"
+	if termination.Last {
+		when = "last exited"
+	}
+	reason := ""
+	if termination.Reason != "" {
+		reason = " (" + termination.Reason + ")"
+	}
+	fmt.Fprintf(out, "Container %v %v with code %v%v: %v\n", container.Name, when, termination.ExitCode, reason, termination.Meaning)
+}
+
+func printResources(container validator.ContainerResult) {
+	if container.Resources == nil {
+		return
+	}
+	format := func(values map[string]string) string {
+		if len(values) == 0 {
+			return "none"
+		}
+		var pairs []string
+		for _, name := range []string{"cpu", "memory"} {
+			if value, ok := values[name]; ok {
+				pairs = append(pairs, name+"="+value)
+			}
+		}
+		return strings.Join(pairs, " ")
+	}
+	fmt.Fprintf(out, "Container %v resources: requests %v, limits %v\n", container.Name, format(container.Resources.Requests), format(container.Resources.Limits))
+	if len(container.Resources.Limits) == 0 {
+		fmt.Fprintf(out, "%v Container %v has no resource limits set, it can use all of the node's memory and is a common OOM culprit\n", banner(yellow, "[NOTE]"), container.Name)
+	}
+}
+
+func printLogs(logs *validator.Logs) {
+	if logs.PreviousError != "" {
+		fmt.Fprintf(out, "\nPrevious instance logs unavailable, falling back to current logs: %v\n", logs.PreviousError)
+	}
+	if logs.Error != "" {
+		fmt.Fprintf(out, "Error getting logs: %v\n", logs.Error)
+		return
+	}
+	title := "Reason for Error"
+	if logs.Previous {
+		title = "Reason for Error (previous instance logs)"
+	}
+	fmt.Fprintf(out, "\n\n[NOTE] %v:\n\n", title)
+	for _, line := range logs.Lines {
+		fmt.Fprintln(out, line)
+	}
+	if logs.Followed {
+		fmt.Fprintf(out, "(including the matching lines logged while following the log)\n")
+	}
+	if logs.ReadError != "" {
+		fmt.Fprintf(out, "Error reading logs: %v\n", logs.ReadError)
+	}
+	if logs.TruncatedAt > 0 {
+		fmt.Fprintf(out, "Log truncated: stopped reading after %v bytes, raise -log-max-bytes or narrow it with -log-tail or -log-since\n", logs.TruncatedAt)
+	}
+}
+
+// writeTimings prints the time spent in each phase after the report, on
+// stderr when stdout only carries the report.
+func writeTimings(timings []validator.Timing, total time.Duration) {
+	w := out
+	if w == io.Discard {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, "\nTimings (%v in total):\n", total.Round(time.Millisecond))
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, timing := range timings {
+		fmt.Fprintf(table, "  %v\t%v\t%vx\n", timing.Phase, timing.Duration.Round(time.Millisecond), timing.Count)
+	}
+	table.Flush()
+}
+
+func printManifestResults(results []validator.ManifestResult) {
+	for _, result := range results {
+		fmt.Fprintf(out, "\n-------------------------------------------------\nManifest check [%v %v/%v]:\n-------------------------------------------------\n\n", result.Kind, result.Namespace, result.Name)
+		if len(result.Findings) == 0 {
+			fmt.Fprintf(out, "No problems found\n")
+		}
+		for _, finding := range result.Findings {
+			fmt.Fprintf(out, "[NOTE] %v: %v\n", finding.Reason, finding.Message)
+		}
+	}
+	if len(results) == 0 {
+		fmt.Fprintf(out, "%v No workloads found in the manifest\n", banner(yellow, "[WARN]"))
+	}
+}
+
+// reportTemplate is the parsed -template, executed against the report
+// instead of printing it as text.
+var reportTemplate *template.Template
+
+// templateFuncs are the functions available in -template in addition to the
+// text/template builtins.
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+// writeReport writes the *validator.Result, []*validator.Result or
+// []validator.ManifestResult to stdout in json or yaml mode or with
+// -template. The YAML is converted from the JSON, so both have the same
+// field names.
+func writeReport(format string, result interface{}) {
+	if reportTemplate != nil {
+		if err := reportTemplate.Execute(os.Stdout, result); err != nil {
+			exit(exitUsage, "error executing -template: %v", err)
+		}
+		return
+	}
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+	case "yaml":
+		data, err := yaml.Marshal(result)
+		if err != nil {
+			slog.Error("Error writing the result as YAML", "error", err)
+			return
+		}
+		os.Stdout.Write(data)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// validateRequest is the body of POST /validate. Deployment is kept for the
+// common case, Kind and Name select any supported workload.
+type validateRequest struct {
+	Namespace  string `json:"namespace"`
+	Deployment string `json:"deployment"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Selector   string `json:"selector"`
+}
+
+// serve runs the validation as an HTTP service until ctx is done. Every
+// request is validated with the flags given on the command line, the target
+// comes from the request body.
+func serve(ctx context.Context, client kubernetes.Interface, dynamicClient dynamic.Interface, opts options) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/validate", func(w http.ResponseWriter, r *http.Request) {
+		handleValidate(w, r, client, dynamicClient, opts)
+	})
+	server := &http.Server{Addr: opts.serve, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	slog.Info("Serving validations", "address", opts.serve)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		exit(exitAPIError, "Error serving on %v: %v", opts.serve, err)
+	}
+}
+
+func handleValidate(w http.ResponseWriter, r *http.Request, client kubernetes.Interface, dynamicClient dynamic.Interface, opts options) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpError(w, http.StatusMethodNotAllowed, "only POST is supported")
+		return
+	}
+	var request validateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&request); err != nil {
+		httpError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	targetOpts := opts.Options
+	targetOpts.Namespace, targetOpts.Selector = request.Namespace, request.Selector
+	targetOpts.Kind, targetOpts.Name = "deployment", request.Deployment
+	if request.Kind != "" {
+		targetOpts.Kind = strings.ToLower(request.Kind)
+	}
+	if request.Name != "" {
+		targetOpts.Name = request.Name
+	}
+	switch {
+	case targetOpts.Namespace == "":
+		httpError(w, http.StatusBadRequest, "namespace is required")
+		return
+	case (targetOpts.Name == "") == (targetOpts.Selector == ""):
+		httpError(w, http.StatusBadRequest, "exactly one of deployment, name or selector is required")
+		return
+	case !isSupportedKind(targetOpts.Kind):
+		httpError(w, http.StatusBadRequest, fmt.Sprintf("unsupported kind %q, must be one of: %v", targetOpts.Kind, strings.Join(validator.SupportedKinds(), ", ")))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), targetOpts.Timeout+diagnosisTimeout)
+	defer cancel()
+	v := validator.New(client, targetOpts)
+	v.Dynamic = dynamicClient
+	result, err := v.Validate(ctx)
+	if err != nil {
+		status := http.StatusBadGateway
+		switch {
+		case apierrors.IsNotFound(err):
+			status = http.StatusNotFound
+		case apierrors.IsForbidden(err):
+			status = http.StatusForbidden
+		case ctx.Err() != nil:
+			status = http.StatusGatewayTimeout
+		}
+		slog.Error(err.Error(), "namespace", targetOpts.Namespace, "name", targetOpts.Name)
+		httpError(w, status, err.Error())
+		return
+	}
+	slog.Info("Validated", "namespace", result.Namespace, "kind", result.Kind, "name", result.Name, "ready", result.Ready)
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(result)
+}
+
+func httpError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
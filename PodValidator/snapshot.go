@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+)
+
+// compareToPrevious prints what changed since the snapshot in path of the
+// last successful validation and, if this one succeeded, replaces it with a
+// snapshot of the result.
+func compareToPrevious(path string, result *validator.Result) {
+	previous, err := readSnapshot(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		slog.Info("No previous snapshot to compare to", "file", path)
+	case err != nil:
+		slog.Warn("Error reading previous snapshot", "file", path, "error", err)
+	case previous.Namespace != result.Namespace || previous.Kind != result.Kind || previous.Name != result.Name:
+		slog.Warn(fmt.Sprintf("Previous snapshot is of %v %v/%v, not comparing", previous.Kind, previous.Namespace, previous.Name), "file", path)
+	default:
+		printChanges(previous, result)
+	}
+
+	if !result.Ready {
+		return
+	}
+	if err := writeSnapshot(path, validator.NewSnapshot(result, time.Now())); err != nil {
+		slog.Warn("Error writing snapshot", "file", path, "error", err)
+	}
+}
+
+func printChanges(previous validator.Snapshot, result *validator.Result) {
+	changes := previous.Changes(result)
+	taken := previous.Taken.Local().Format(time.RFC3339)
+	if len(changes) == 0 {
+		fmt.Fprintf(out, "No changes since the last successful validation at %v\n", taken)
+		return
+	}
+	fmt.Fprintf(out, "Changes since the last successful validation at %v:\n", taken)
+	for _, change := range changes {
+		fmt.Fprintf(out, "%v %v\n", banner(yellow, "[NOTE]"), change)
+	}
+}
+
+func readSnapshot(path string) (validator.Snapshot, error) {
+	var snapshot validator.Snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, err
+	}
+	if snapshot.Version != validator.SnapshotVersion {
+		return snapshot, fmt.Errorf("unsupported snapshot version %v, want %v", snapshot.Version, validator.SnapshotVersion)
+	}
+	return snapshot, nil
+}
+
+func writeSnapshot(path string, snapshot validator.Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
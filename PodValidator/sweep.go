@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/karthikeyans02/Kubernetes/PodValidator/validator"
+	"k8s.io/client-go/kubernetes"
+)
+
+// sweep validates the targets matching -selector in every namespace, one after
+// the other, and ends with a summary of the failures across namespaces.
+func sweep(ctx context.Context, client kubernetes.Interface, opts options) {
+	kind := ""
+	if opts.kindSet {
+		kind = opts.Kind
+	}
+	listCtx, cancel := context.WithTimeout(ctx, diagnosisTimeout)
+	targets, err := validator.FindTargets(listCtx, client, kind, opts.Selector)
+	cancel()
+	if err != nil {
+		exit(apiExitCode(err), "%v", apiErrorMessage(err, opts.Options))
+	}
+	if len(targets) == 0 {
+		exit(exitNotReady, "Nothing matches selector %v in any namespace.", opts.Selector)
+	}
+
+	var results []*validator.Result
+	var failures []string
+	code := exitSuccess
+	for _, target := range targets {
+		fmt.Fprintf(out, "\n==================================================\nNamespace [%v]:\n==================================================\n", target.Namespace)
+		targetOpts := opts.Options
+		targetOpts.Namespace = target.Namespace
+		name := opts.Selector
+		if target.Name != "" {
+			targetOpts.Name, targetOpts.Selector = target.Name, ""
+			name = target.Name
+		}
+
+		// Every target gets the full wait and diagnosis time.
+		targetCtx, cancel := context.WithTimeout(ctx, opts.Timeout+diagnosisTimeout)
+		result, err := validator.New(client, targetOpts).Validate(targetCtx)
+		cancel()
+		if ctx.Err() != nil {
+			exit(exitNotReady, "Validation aborted: %v", ctx.Err())
+		}
+		if err != nil {
+			slog.Error(apiErrorMessage(err, targetOpts), "namespace", target.Namespace)
+			failures = append(failures, fmt.Sprintf("%v/%v: %v", target.Namespace, name, err))
+			if code == exitSuccess {
+				code = apiExitCode(err)
+			}
+			continue
+		}
+		printResult(result)
+		results = append(results, result)
+		if !result.Ready {
+			failures = append(failures, fmt.Sprintf("%v/%v %v: %v", result.Namespace, result.Kind, result.Name, result.Summary))
+			code = exitNotReady
+		}
+	}
+
+	writeReport(opts.output, results)
+	fmt.Fprintf(out, "\n\n==================================================\nSummary: %v of %v passed in all namespaces\n==================================================\n", len(targets)-len(failures), len(targets))
+	for _, failure := range failures {
+		fmt.Fprintf(out, "%v %v\n", banner(red, "[Error]"), failure)
+	}
+	if code != exitSuccess {
+		os.Exit(code)
+	}
+}
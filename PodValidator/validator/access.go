@@ -0,0 +1,145 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// kindResources maps the accepted Options.Kind values to their resources.
+var kindResources = map[string]schema.GroupResource{
+	"cronjob":          {Group: "batch", Resource: "cronjobs"},
+	"daemonset":        {Group: "apps", Resource: "daemonsets"},
+	"deployment":       {Group: "apps", Resource: "deployments"},
+	"deploymentconfig": {Group: deploymentConfigs.Group, Resource: deploymentConfigs.Resource},
+	"job":              {Group: "batch", Resource: "jobs"},
+	"replicaset":       {Group: "apps", Resource: "replicasets"},
+	"service":          {Resource: "services"},
+	"statefulset":      {Group: "apps", Resource: "statefulsets"},
+}
+
+// Permission is an API permission the validation uses. Without a Required
+// one it cannot run at all, without the others the diagnosis is incomplete.
+// A ClusterScoped one, such as on nodes, is not granted by a namespaced Role.
+type Permission struct {
+	Verb          string
+	Group         string
+	Resource      string
+	Subresource   string
+	Required      bool
+	ClusterScoped bool
+}
+
+func (p Permission) String() string {
+	return p.Verb + " " + p.ResourceName()
+}
+
+// ResourceName is the resource in the resource.group/subresource form of
+// kubectl create role --resource.
+func (p Permission) ResourceName() string {
+	resource := p.Resource
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	if p.Subresource != "" {
+		resource += "/" + p.Subresource
+	}
+	return resource
+}
+
+// Permissions lists what the validation described by options uses in its
+// namespace.
+func Permissions(options Options) []Permission {
+	var permissions []Permission
+	if options.Selector == "" {
+		resource := kindResources[options.Kind]
+		if options.Resource != nil {
+			resource = options.Resource.GVR.GroupResource()
+		}
+		permissions = append(permissions, Permission{Verb: "get", Group: resource.Group, Resource: resource.Resource, Required: true})
+		if options.Watch {
+			watched := resource
+			if options.Kind == "service" && options.Resource == nil {
+				// The endpoints change as the pods become ready, not the Service.
+				watched = schema.GroupResource{Resource: "endpoints"}
+			}
+			permissions = append(permissions, Permission{Verb: "watch", Group: watched.Group, Resource: watched.Resource})
+		}
+		if options.Kind == "deployment" && options.Resource == nil {
+			// Without the ReplicaSets the pods of every revision are diagnosed.
+			permissions = append(permissions, Permission{Verb: "list", Group: "apps", Resource: "replicasets"})
+		}
+		if options.Kind == "cronjob" && options.Resource == nil {
+			permissions = append(permissions, Permission{Verb: "list", Group: "batch", Resource: "jobs", Required: true})
+		}
+		if options.Kind == "service" && options.Resource == nil {
+			permissions = append(permissions,
+				Permission{Verb: "list", Group: "discovery.k8s.io", Resource: "endpointslices"},
+				Permission{Verb: "get", Resource: "endpoints"},
+			)
+		}
+	}
+	permissions = append(permissions,
+		Permission{Verb: "list", Resource: "pods", Required: true},
+		Permission{Verb: "get", Resource: "pods"},
+		Permission{Verb: "get", Resource: "pods", Subresource: "log"},
+		Permission{Verb: "list", Resource: "events"},
+		Permission{Verb: "get", Resource: "secrets"},
+		Permission{Verb: "get", Resource: "persistentvolumeclaims"},
+		Permission{Verb: "list", Resource: "persistentvolumes", ClusterScoped: true},
+		Permission{Verb: "get", Group: "storage.k8s.io", Resource: "storageclasses", ClusterScoped: true},
+		Permission{Verb: "get", Resource: "nodes", ClusterScoped: true},
+	)
+	if options.FollowEvents {
+		permissions = append(permissions, Permission{Verb: "watch", Resource: "events"})
+	}
+	if options.ServiceProbe != nil {
+		permissions = append(permissions,
+			Permission{Verb: "list", Resource: "services"},
+			Permission{Verb: "get", Resource: "services", Subresource: "proxy"},
+		)
+	}
+	return permissions
+}
+
+// CheckAccess asks the API server with SelfSubjectAccessReviews which of the
+// permissions the validation uses are denied in the namespace, so that a
+// missing permission is reported up front instead of as a 403 halfway
+// through the diagnosis.
+func (v *Validator) CheckAccess(ctx context.Context) ([]Permission, error) {
+	defer v.track("check access", time.Now())
+	var denied []Permission
+	for _, permission := range Permissions(v.options) {
+		namespace := v.options.Namespace
+		if permission.ClusterScoped {
+			namespace = ""
+		}
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace:   namespace,
+					Verb:        permission.Verb,
+					Group:       permission.Group,
+					Resource:    permission.Resource,
+					Subresource: permission.Subresource,
+				},
+			},
+		}
+		var response *authorizationv1.SelfSubjectAccessReview
+		err := v.retry(ctx, "checking access", func() (err error) {
+			response, err = v.client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error checking access to %v: %w", permission, err)
+		}
+		if !response.Status.Allowed {
+			denied = append(denied, permission)
+		}
+	}
+	return denied, nil
+}
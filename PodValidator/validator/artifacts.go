@@ -0,0 +1,96 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"sigs.k8s.io/yaml"
+)
+
+// Artifacts are the raw files of a pod for a support bundle: the pod and its
+// events as YAML and the full logs of its containers. Errors lists the files
+// that could not be collected.
+type Artifacts struct {
+	Pod    string
+	Files  []ArtifactFile
+	Errors []string
+}
+
+// ArtifactFile is a file of Artifacts, Name is relative to the directory of
+// the pod, e.g. logs/app.log.
+type ArtifactFile struct {
+	Name string
+	Data []byte
+}
+
+// CollectArtifacts gets the pod, its events and the whole current and, for
+// restarted containers, previous log of each container, unfiltered by
+// LogOptions except for its Timeout on fetching each log.
+func (v *Validator) CollectArtifacts(ctx context.Context, podName string) (*Artifacts, error) {
+	namespace := v.options.Namespace
+	var pod *v1.Pod
+	err := v.retry(ctx, "getting pod", func() (err error) {
+		pod, err = v.client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	artifacts := &Artifacts{Pod: podName}
+	pod.ManagedFields = nil
+	pod.APIVersion, pod.Kind = "v1", "Pod"
+	artifacts.add("pod.yaml", pod)
+
+	selector := fields.Set{"involvedObject.kind": "Pod", "involvedObject.name": podName}.AsSelector().String()
+	var events *v1.EventList
+	err = v.retry(ctx, "listing events", func() (err error) {
+		events, err = v.client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+		return err
+	})
+	if err != nil {
+		artifacts.Errors = append(artifacts.Errors, fmt.Sprintf("events.yaml: %v", err))
+	} else {
+		events.APIVersion, events.Kind = "v1", "EventList"
+		artifacts.add("events.yaml", events)
+	}
+
+	statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		v.collectLog(ctx, artifacts, status.Name, false)
+		if status.RestartCount > 0 {
+			v.collectLog(ctx, artifacts, status.Name, true)
+		}
+	}
+	return artifacts, nil
+}
+
+func (v *Validator) collectLog(ctx context.Context, artifacts *Artifacts, container string, previous bool) {
+	name := "logs/" + container + ".log"
+	if previous {
+		name = "logs/" + container + ".previous.log"
+	}
+	ctx, cancel, timedOut := v.logContext(ctx)
+	defer cancel()
+	var data []byte
+	err := v.retry(ctx, "getting logs", func() (err error) {
+		data, err = v.client.CoreV1().Pods(v.options.Namespace).GetLogs(artifacts.Pod, &v1.PodLogOptions{Container: container, Previous: previous}).DoRaw(ctx)
+		return err
+	})
+	if err != nil {
+		artifacts.Errors = append(artifacts.Errors, fmt.Sprintf("%v: %v", name, timedOut(err)))
+		return
+	}
+	artifacts.Files = append(artifacts.Files, ArtifactFile{Name: name, Data: data})
+}
+
+func (a *Artifacts) add(name string, object interface{}) {
+	data, err := yaml.Marshal(object)
+	if err != nil {
+		a.Errors = append(a.Errors, fmt.Sprintf("%v: %v", name, err))
+		return
+	}
+	a.Files = append(a.Files, ArtifactFile{Name: name, Data: data})
+}
@@ -0,0 +1,81 @@
+package validator
+
+// Category is the kind of failure a Diagnosis is, grouping its many specific
+// reasons.
+type Category string
+
+const (
+	CategoryImagePull    Category = "ImagePullBackOff"
+	CategoryCrashLoop    Category = "CrashLoopBackOff"
+	CategoryOOMKilled    Category = "OOMKilled"
+	CategoryConfigError  Category = "ConfigError"
+	CategoryPending      Category = "Pending"
+	CategoryProbeFailure Category = "ProbeFailure"
+	CategoryNode         Category = "Node"
+	CategoryUnknown      Category = "Unknown"
+)
+
+// reasonCategories maps the reasons of Diagnosis to their Category.
+var reasonCategories = map[string]Category{
+	"ImagePullBackOff":             CategoryImagePull,
+	"ErrImagePull":                 CategoryImagePull,
+	"InvalidImageName":             CategoryImagePull,
+	"ImagePullSecret":              CategoryImagePull,
+	"ImagePullPolicy":              CategoryImagePull,
+	"ErrImageNeverPull":            CategoryImagePull,
+	"CrashLoopBackOff":             CategoryCrashLoop,
+	"RunContainerError":            CategoryCrashLoop,
+	"InitContainerFailed":          CategoryCrashLoop,
+	"OOMKilled":                    CategoryOOMKilled,
+	"CreateContainerConfigError":   CategoryConfigError,
+	"CreateContainerError":         CategoryConfigError,
+	"FailedMount":                  CategoryConfigError,
+	"FailedAttachVolume":           CategoryConfigError,
+	"ResourceLimits":               CategoryConfigError,
+	"FailedScheduling":             CategoryPending,
+	"PodInitializing":              CategoryPending,
+	"ContainerCreating":            CategoryPending,
+	"SidecarNotReady":              CategoryPending,
+	"UnboundPersistentVolumeClaim": CategoryPending,
+	"ReadinessProbeFailed":         CategoryProbeFailure,
+	"LivenessProbeFailed":          CategoryProbeFailure,
+	"StartupProbeFailed":           CategoryProbeFailure,
+	"NodeLost":                     CategoryNode,
+	"NodeUnhealthy":                CategoryNode,
+	"Evicted":                      CategoryNode,
+}
+
+// Categorize returns the Category of a diagnosis reason, CategoryUnknown for
+// reasons without one.
+func Categorize(reason string) Category {
+	if category, ok := reasonCategories[reason]; ok {
+		return category
+	}
+	return CategoryUnknown
+}
+
+// classify sets the Category of the diagnoses of a pod and its containers
+// and, where the reason has an explanation, the steps to fix it as Hints.
+func classify(result *PodResult, namespace string) {
+	classifyDiagnoses(result.Diagnosis, namespace, result.Name, "")
+	for i := range result.Containers {
+		classifyDiagnoses(result.Containers[i].Diagnosis, namespace, result.Name, result.Containers[i].Name)
+	}
+}
+
+// categorize sets the Category of diagnoses without adding Hints, such as the
+// findings of a manifest that has no pods yet.
+func categorize(diagnoses []Diagnosis) {
+	for i := range diagnoses {
+		diagnoses[i].Category = Categorize(diagnoses[i].Reason)
+	}
+}
+
+func classifyDiagnoses(diagnoses []Diagnosis, namespace, pod, container string) {
+	categorize(diagnoses)
+	for i := range diagnoses {
+		if explanation, ok := Explain(diagnoses[i].Reason, namespace, pod, container); ok {
+			diagnoses[i].Hints = explanation.Steps
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// claimDiagnosis reports the PersistentVolumeClaims of a pending pod that do
+// not exist or are not bound yet. The scheduler waits for them without the
+// containers ever logging anything.
+func (v *Validator) claimDiagnosis(ctx context.Context, pod v1.Pod) ([]Diagnosis, error) {
+	var diagnosis []Diagnosis
+	var volumes *v1.PersistentVolumeList
+	for _, volume := range pod.Spec.Volumes {
+		var name string
+		switch {
+		case volume.PersistentVolumeClaim != nil:
+			name = volume.PersistentVolumeClaim.ClaimName
+		case volume.Ephemeral != nil:
+			name = pod.Name + "-" + volume.Name
+		default:
+			continue
+		}
+
+		var claim *v1.PersistentVolumeClaim
+		err := v.retry(ctx, "getting persistent volume claim", func() (err error) {
+			claim, err = v.client.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx, name, metav1.GetOptions{})
+			return err
+		})
+		if apierrors.IsNotFound(err) {
+			diagnosis = append(diagnosis, Diagnosis{Reason: "UnboundPersistentVolumeClaim", Message: fmt.Sprintf("Volume %v uses PersistentVolumeClaim %v which does not exist in namespace %v, please add it", volume.Name, name, pod.Namespace)})
+			continue
+		}
+		if err != nil {
+			return diagnosis, fmt.Errorf("error getting persistent volume claim %v: %w", name, err)
+		}
+		if claim.Status.Phase != v1.ClaimPending {
+			continue
+		}
+
+		storageClass := "the default StorageClass"
+		if claim.Spec.StorageClassName != nil {
+			storageClass = fmt.Sprintf("StorageClass %q", *claim.Spec.StorageClassName)
+		}
+		message := fmt.Sprintf("PersistentVolumeClaim %v of volume %v is Pending (%v, %v %v)", name, volume.Name, storageClass, claimRequest(claim), accessModes(claim.Spec.AccessModes))
+		if claim.Spec.StorageClassName != nil && *claim.Spec.StorageClassName != "" {
+			class := *claim.Spec.StorageClassName
+			if _, err := v.client.StorageV1().StorageClasses().Get(ctx, class, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+				message += fmt.Sprintf(", StorageClass %v does not exist", class)
+			}
+		}
+		if volumes == nil {
+			err := v.retry(ctx, "listing persistent volumes", func() (err error) {
+				volumes, err = v.client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+				return err
+			})
+			if err != nil {
+				v.logger().Warn("Could not list the persistent volumes", "error", err)
+				volumes = &v1.PersistentVolumeList{}
+			}
+		}
+		if matching := matchingVolumes(claim, volumes.Items); len(matching) > 0 {
+			message += fmt.Sprintf(", Available PersistentVolumes that match it: %v", strings.Join(matching, ", "))
+		} else {
+			message += ", no Available PersistentVolume matches it so it waits for the StorageClass to provision one"
+		}
+		message += fmt.Sprintf(", check kubectl describe pvc %v -n %v for the provisioning events", name, pod.Namespace)
+		diagnosis = append(diagnosis, Diagnosis{Reason: "UnboundPersistentVolumeClaim", Message: message})
+	}
+	return diagnosis, nil
+}
+
+// matchingVolumes returns the names of the Available PersistentVolumes with
+// the storage class, access modes and capacity the claim asks for.
+func matchingVolumes(claim *v1.PersistentVolumeClaim, volumes []v1.PersistentVolume) []string {
+	class := ""
+	if claim.Spec.StorageClassName != nil {
+		class = *claim.Spec.StorageClassName
+	}
+	request := claim.Spec.Resources.Requests[v1.ResourceStorage]
+	var matching []string
+	for _, volume := range volumes {
+		if volume.Status.Phase != v1.VolumeAvailable || volume.Spec.StorageClassName != class {
+			continue
+		}
+		if capacity := volume.Spec.Capacity[v1.ResourceStorage]; capacity.Cmp(request) < 0 {
+			continue
+		}
+		if !hasAccessModes(volume.Spec.AccessModes, claim.Spec.AccessModes) {
+			continue
+		}
+		matching = append(matching, volume.Name)
+	}
+	return matching
+}
+
+func hasAccessModes(modes, wanted []v1.PersistentVolumeAccessMode) bool {
+	for _, mode := range wanted {
+		found := false
+		for _, available := range modes {
+			if available == mode {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func claimRequest(claim *v1.PersistentVolumeClaim) string {
+	if request, ok := claim.Spec.Resources.Requests[v1.ResourceStorage]; ok {
+		return "requests " + request.String()
+	}
+	return "no storage request"
+}
+
+func accessModes(modes []v1.PersistentVolumeAccessMode) string {
+	names := make([]string, len(modes))
+	for i, mode := range modes {
+		names[i] = string(mode)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,25 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Condition is a status condition to wait for, e.g. Progressing=True.
+type Condition struct {
+	Type   string
+	Status string
+}
+
+func (c Condition) String() string {
+	return c.Type + "=" + c.Status
+}
+
+// ParseCondition parses Type=Status, e.g. Progressing=True.
+func ParseCondition(condition string) (Condition, error) {
+	conditionType, status, ok := strings.Cut(condition, "=")
+	if !ok || conditionType == "" || status == "" {
+		return Condition{}, fmt.Errorf("%q is not Type=Status", condition)
+	}
+	return Condition{Type: conditionType, Status: status}, nil
+}
@@ -0,0 +1,96 @@
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// CustomResource selects a workload of any resource, such as a custom
+// workload CRD, that is read with the dynamic client.
+type CustomResource struct {
+	GVR schema.GroupVersionResource
+	// ReadyPath is the JSONPath of the readiness field, e.g.
+	// {.status.conditions[?(@.type=="Ready")].status}.
+	ReadyPath string
+	// ReadyValue is the value of ReadyPath once the workload is ready.
+	ReadyValue string
+}
+
+// ParseGVR parses group/version/resource, or version/resource for the core
+// group, e.g. argoproj.io/v1alpha1/rollouts.
+func ParseGVR(gvr string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(gvr, "/")
+	switch {
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}, nil
+	case len(parts) == 3 && parts[0] != "" && parts[1] != "" && parts[2] != "":
+		return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}, nil
+	}
+	return schema.GroupVersionResource{}, fmt.Errorf("%q is not group/version/resource", gvr)
+}
+
+// ParseReadyPath checks that path is a valid JSONPath template.
+func ParseReadyPath(path string) error {
+	return jsonpath.New("ready").Parse(path)
+}
+
+// customStatus reports the readiness field of a custom resource and resolves
+// its pods from spec.selector, a label selector or a map of labels, or else
+// the status.selector string kept for the scale subresource.
+func customStatus(resource *CustomResource, object *unstructured.Unstructured) (workload, error) {
+	selector, err := objectSelector(object)
+	if err != nil {
+		return workload{}, err
+	}
+	if selector == "" {
+		return workload{}, fmt.Errorf("the selector of %v %v is empty and would match every pod", resource.GVR.Resource, object.GetName())
+	}
+	w := workload{selector: selector}
+
+	path := jsonpath.New("ready").AllowMissingKeys(true)
+	if err := path.Parse(resource.ReadyPath); err != nil {
+		return workload{}, fmt.Errorf("invalid ready JSONPath %q: %w", resource.ReadyPath, err)
+	}
+	var value bytes.Buffer
+	if err := path.Execute(&value, object.Object); err != nil {
+		return workload{}, fmt.Errorf("error evaluating %v of %v %v: %w", resource.ReadyPath, resource.GVR.Resource, object.GetName(), err)
+	}
+	w.report("%v %v: %v is %q, ready when %q", resource.GVR.Resource, object.GetName(), resource.ReadyPath, value.String(), resource.ReadyValue)
+	w.ready = strings.TrimSpace(value.String()) == resource.ReadyValue
+	return w, nil
+}
+
+func objectSelector(object *unstructured.Unstructured) (string, error) {
+	if selector, found, _ := unstructured.NestedMap(object.Object, "spec", "selector"); found {
+		if _, ok := selector["matchLabels"]; !ok {
+			if _, ok := selector["matchExpressions"]; !ok {
+				set, _, err := unstructured.NestedStringMap(object.Object, "spec", "selector")
+				if err != nil {
+					return "", fmt.Errorf("invalid spec.selector of %v: %w", object.GetName(), err)
+				}
+				return labels.SelectorFromSet(set).String(), nil
+			}
+		}
+		var labelSelector metav1.LabelSelector
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selector, &labelSelector); err != nil {
+			return "", fmt.Errorf("invalid spec.selector of %v: %w", object.GetName(), err)
+		}
+		parsed, err := metav1.LabelSelectorAsSelector(&labelSelector)
+		if err != nil {
+			return "", fmt.Errorf("invalid spec.selector of %v: %w", object.GetName(), err)
+		}
+		return parsed.String(), nil
+	}
+	if selector, found, _ := unstructured.NestedString(object.Object, "status", "selector"); found {
+		return selector, nil
+	}
+	return "", fmt.Errorf("%v has neither spec.selector nor status.selector to find its pods", object.GetName())
+}
@@ -0,0 +1,26 @@
+package validator
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestParseGVR(t *testing.T) {
+	tests := []struct {
+		gvr     string
+		want    schema.GroupVersionResource
+		wantErr bool
+	}{
+		{gvr: "argoproj.io/v1alpha1/rollouts", want: schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}},
+		{gvr: "v1/replicationcontrollers", want: schema.GroupVersionResource{Version: "v1", Resource: "replicationcontrollers"}},
+		{gvr: "rollouts", wantErr: true},
+		{gvr: "argoproj.io//rollouts", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := ParseGVR(test.gvr)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("ParseGVR(%q) = %v, %v, want %v, error %v", test.gvr, got, err, test.want, test.wantErr)
+		}
+	}
+}
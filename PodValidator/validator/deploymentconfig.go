@@ -0,0 +1,72 @@
+package validator
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// deploymentConfigs is the OpenShift DeploymentConfig resource. It is read
+// with the dynamic client so that no OpenShift client is needed.
+var deploymentConfigs = schema.GroupVersionResource{Group: "apps.openshift.io", Version: "v1", Resource: "deploymentconfigs"}
+
+// deploymentConfigStatus reports the replica counts and latest version of an
+// OpenShift DeploymentConfig. Only the pods of the latest version are
+// selected, its pods are labeled deployment=<name>-<version>. A
+// DeploymentConfig that exceeded its progress deadline has failed, one scaled
+// to 0 replicas is ready. Without a selector every pod of the namespace would
+// match, that is an error.
+func deploymentConfigStatus(object *unstructured.Unstructured) (workload, error) {
+	name := object.GetName()
+	selector, _, err := unstructured.NestedStringMap(object.Object, "spec", "selector")
+	if err != nil {
+		return workload{}, fmt.Errorf("invalid selector of DeploymentConfig %v: %w", name, err)
+	}
+	if len(selector) == 0 {
+		return workload{}, fmt.Errorf("the DeploymentConfig %v has no selector", name)
+	}
+	replicas, found, _ := unstructured.NestedInt64(object.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	status := func(field string) int64 {
+		value, _, _ := unstructured.NestedInt64(object.Object, "status", field)
+		return value
+	}
+	latestVersion := status("latestVersion")
+	if latestVersion > 0 {
+		selector = labels.Merge(selector, labels.Set{"deployment": fmt.Sprintf("%v-%v", name, latestVersion)})
+	}
+
+	w := workload{selector: labels.SelectorFromSet(selector).String()}
+	w.report("DeploymentConfig %v: %v/%v replicas ready, %v updated, %v available (version %v)", name, status("readyReplicas"), replicas, status("updatedReplicas"), status("availableReplicas"), latestVersion)
+	if replicas == 0 {
+		w.scaledToZero(name)
+		return w, nil
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, condition := range conditions {
+		condition, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditionType, _, _ := unstructured.NestedString(condition, "type")
+		conditionStatus, _, _ := unstructured.NestedString(condition, "status")
+		reason, _, _ := unstructured.NestedString(condition, "reason")
+		message, _, _ := unstructured.NestedString(condition, "message")
+		if conditionType == "Progressing" && message != "" {
+			w.report("Progressing: %v", message)
+		}
+		if conditionType == "Progressing" && reason == "ProgressDeadlineExceeded" {
+			w.report("DeploymentConfig %v exceeded its progress deadline", name)
+			w.failed = true
+		}
+		if conditionType == "Available" && conditionStatus == "True" {
+			w.ready = status("observedGeneration") >= object.GetGeneration() && status("updatedReplicas") >= replicas
+		}
+	}
+	return w, nil
+}
@@ -0,0 +1,132 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// warningEvents returns the Warning events of a pod, oldest first. Events
+// explain scheduling, volume and sandbox failures that never reach the logs.
+func (v *Validator) warningEvents(ctx context.Context, pod v1.Pod) ([]Event, error) {
+	selector := fields.Set{
+		"involvedObject.kind": "Pod",
+		"involvedObject.name": pod.Name,
+		"type":                v1.EventTypeWarning,
+	}.AsSelector().String()
+	defer v.track("list events", time.Now())
+	var list *v1.EventList
+	err := v.retry(ctx, "listing events", func() (err error) {
+		list, err = v.client.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := list.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return eventTime(items[i]).Before(eventTime(items[j]))
+	})
+	events := make([]Event, 0, len(items))
+	for _, event := range items {
+		events = append(events, Event{Reason: event.Reason, Message: event.Message, Count: event.Count, Container: eventContainer(event)})
+	}
+	return events, nil
+}
+
+// eventContainer returns the container name from an involvedObject.fieldPath
+// such as "spec.containers{app}".
+func eventContainer(event v1.Event) string {
+	path := event.InvolvedObject.FieldPath
+	start, end := strings.Index(path, "{"), strings.LastIndex(path, "}")
+	if start < 0 || end < start {
+		return ""
+	}
+	return path[start+1 : end]
+}
+
+func eventTime(event v1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// followEvents logs the Warning events of the pods matching selector as they
+// occur, until the returned stop function is called.
+func (v *Validator) followEvents(ctx context.Context, selector string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := v.watchEvents(ctx, selector); err != nil && ctx.Err() == nil {
+			v.logger().Warn("Stopped following events", "error", err)
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+}
+
+func (v *Validator) watchEvents(ctx context.Context, selector string) error {
+	podSelector, err := labels.Parse(selector)
+	if err != nil {
+		return err
+	}
+	events := v.client.CoreV1().Events(v.options.Namespace)
+	fieldSelector := fields.Set{"involvedObject.kind": "Pod", "type": v1.EventTypeWarning}.AsSelector().String()
+	// Start from the current resource version so that only new events are logged.
+	list, err := events.List(ctx, metav1.ListOptions{FieldSelector: fieldSelector, Limit: 1})
+	if err != nil {
+		return err
+	}
+	watcher, err := events.Watch(ctx, metav1.ListOptions{FieldSelector: fieldSelector, ResourceVersion: list.ResourceVersion})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	// Events do not carry the pod labels, so each pod is looked up once.
+	matches := map[string]bool{}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case change, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+			event, isEvent := change.Object.(*v1.Event)
+			if !isEvent || (change.Type != watch.Added && change.Type != watch.Modified) {
+				continue
+			}
+			name := event.InvolvedObject.Name
+			match, known := matches[name]
+			if !known {
+				pod, err := v.client.CoreV1().Pods(v.options.Namespace).Get(ctx, name, metav1.GetOptions{})
+				match = err == nil && podSelector.Matches(labels.Set(pod.Labels))
+				matches[name] = match
+			}
+			if match {
+				v.logger().Warn(fmt.Sprintf("Event %v on pod %v: %v", event.Reason, name, event.Message), "count", event.Count)
+			}
+		}
+	}
+}
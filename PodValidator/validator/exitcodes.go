@@ -0,0 +1,88 @@
+package validator
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// signalNames are the signals commonly behind a container exit code of
+// 128+n.
+var signalNames = map[int32]string{
+	1:  "SIGHUP",
+	2:  "SIGINT",
+	3:  "SIGQUIT",
+	4:  "SIGILL",
+	6:  "SIGABRT",
+	7:  "SIGBUS",
+	8:  "SIGFPE",
+	9:  "SIGKILL",
+	11: "SIGSEGV",
+	13: "SIGPIPE",
+	15: "SIGTERM",
+}
+
+// signalMeanings explain the signals a container is usually killed with.
+var signalMeanings = map[int32]string{
+	2:  "interrupted",
+	6:  "aborted, e.g. by a failed assertion or an uncaught exception in native code",
+	7:  "bus error, e.g. a memory-mapped file that went away",
+	8:  "arithmetic error, e.g. an integer division by zero",
+	9:  "killed, by the kernel OOM killer when the reason is OOMKilled, otherwise by the kubelet after a failed liveness probe or the termination grace period",
+	11: "segmentation fault, the process accessed invalid memory",
+	15: "terminated, e.g. on shutdown or after a failed liveness probe",
+}
+
+// containerTermination reports the current termination of a container or,
+// while it is restarted, the last one.
+func containerTermination(container v1.ContainerStatus) *Termination {
+	terminated, last := container.State.Terminated, false
+	if terminated == nil {
+		terminated, last = container.LastTerminationState.Terminated, true
+	}
+	if terminated == nil {
+		return nil
+	}
+	return &Termination{
+		Last:     last,
+		ExitCode: terminated.ExitCode,
+		Signal:   terminated.Signal,
+		Reason:   terminated.Reason,
+		Meaning:  exitMeaning(terminated.ExitCode, terminated.Signal),
+	}
+}
+
+// exitMeaning interprets an exit code the way a shell reports it, codes
+// above 128 being 128 plus the number of the signal that killed the process.
+func exitMeaning(code, signal int32) string {
+	if signal == 0 && code > 128 && code < 128+65 {
+		signal = code - 128
+	}
+	if signal != 0 {
+		name, ok := signalNames[signal]
+		if !ok {
+			name = fmt.Sprintf("signal %v", signal)
+		}
+		if meaning, ok := signalMeanings[signal]; ok {
+			return fmt.Sprintf("%v, %v", name, meaning)
+		}
+		return name
+	}
+	switch code {
+	case 0:
+		return "exited successfully"
+	case 1:
+		return "general application error, see the logs"
+	case 2:
+		return "invalid arguments or misuse of a shell builtin"
+	case 126:
+		return "command is not executable, e.g. permission denied"
+	case 127:
+		return "command not found, check the command and the PATH of the image"
+	case 128:
+		return "invalid exit argument"
+	case 255:
+		return "exit status out of range, e.g. exit(-1)"
+	}
+	return "application specific exit code, see the logs"
+}
@@ -0,0 +1,42 @@
+package validator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestExitMeaning(t *testing.T) {
+	tests := []struct {
+		code, signal int32
+		want         string
+	}{
+		{code: 1, want: "general application error"},
+		{code: 127, want: "command not found"},
+		{code: 137, want: "SIGKILL, killed"},
+		{code: 139, want: "SIGSEGV, segmentation fault"},
+		{code: 143, want: "SIGTERM, terminated"},
+		{code: 0, signal: 6, want: "SIGABRT, aborted"},
+		{code: 158, want: "signal 30"},
+		{code: 42, want: "application specific exit code"},
+	}
+	for _, test := range tests {
+		if got := exitMeaning(test.code, test.signal); !strings.HasPrefix(got, test.want) {
+			t.Errorf("exitMeaning(%v, %v) = %q, want prefix %q", test.code, test.signal, got, test.want)
+		}
+	}
+}
+
+func TestContainerTermination(t *testing.T) {
+	container := waitingContainer("CrashLoopBackOff", 3)
+	container.LastTerminationState.Terminated = &v1.ContainerStateTerminated{ExitCode: 139, Reason: "Error"}
+	want := &Termination{Last: true, ExitCode: 139, Reason: "Error", Meaning: "SIGSEGV, segmentation fault, the process accessed invalid memory"}
+	if got := containerTermination(container); !reflect.DeepEqual(got, want) {
+		t.Errorf("containerTermination() = %+v, want %+v", got, want)
+	}
+	if got := containerTermination(waitingContainer("ImagePullBackOff", 0)); got != nil {
+		t.Errorf("expected no termination for a container that never ran, got %+v", got)
+	}
+}
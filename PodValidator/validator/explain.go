@@ -0,0 +1,121 @@
+package validator
+
+import "strings"
+
+// Explanation is the cause of a failure reason and the steps to fix it, for
+// readers who have not debugged it before.
+type Explanation struct {
+	Cause string   `json:"cause"`
+	Steps []string `json:"steps"`
+}
+
+// explanations is keyed by the reasons of Diagnosis and of waiting and
+// terminated containers. {namespace}, {pod} and {container} in the steps are
+// replaced by Explain.
+var explanations = map[string]Explanation{
+	"ImagePullBackOff": {
+		Cause: "The kubelet could not pull the container image and is backing off before retrying.",
+		Steps: []string{
+			"Run kubectl describe pod {pod} -n {namespace} and read the Failed event for the exact registry error (not found, unauthorized, timeout).",
+			"Check the image name and tag with kubectl get pod {pod} -n {namespace} -o jsonpath='{.spec.containers[*].image}', a typo or a tag that was never pushed is the most common cause.",
+			"For a private registry, check spec.imagePullSecrets and that the secret has credentials for the image's registry: kubectl get secret <name> -n {namespace} -o jsonpath='{.data.\\.dockerconfigjson}' | base64 -d.",
+		},
+	},
+	"CrashLoopBackOff": {
+		Cause: "The container starts and exits again, so the kubelet restarts it with an increasing delay.",
+		Steps: []string{
+			"Read the logs of the crashed instance: kubectl logs {pod} -n {namespace} -c {container} --previous.",
+			"Check the exit code under Last State in kubectl describe pod {pod} -n {namespace}: 1 is usually an application error, 137 a kill (OOM or a failing liveness probe), 127 a command that does not exist.",
+			"Check the command, args and env of the container and the config maps and secrets it reads at startup.",
+		},
+	},
+	"SidecarNotReady": {
+		Cause: "A native sidecar, an init container with restartPolicy Always, is not up, and the main containers only start once it has started.",
+		Steps: []string{
+			"Read the logs of the sidecar: kubectl logs {pod} -n {namespace} -c {container} --previous.",
+			"Check the startupProbe and readinessProbe of the sidecar in spec.initContainers, the main containers wait for its startup probe to succeed.",
+			"Check the image, command and config of the sidecar, e.g. a service mesh proxy that cannot reach its control plane.",
+		},
+	},
+	"Evicted": {
+		Cause: "The kubelet evicted the pod because its node ran low on memory, disk or process IDs, evicting the pods that use the most above their requests first.",
+		Steps: []string{
+			"Check the pressure and allocated resources of the node with kubectl describe node <node>.",
+			"Set the memory and ephemeral-storage requests of the containers close to their real usage, pods using more than they request are evicted first.",
+			"If many pods are evicted at once, add node capacity or spread the workload, and clean up the evicted pods with kubectl delete pod -n {namespace} --field-selector=status.phase=Failed.",
+		},
+	},
+	"NodeLost": {
+		Cause: "The node the pod ran on went away or stopped reporting to the API server, so the state of its containers is unknown and their logs are gone.",
+		Steps: []string{
+			"Check the node with kubectl get node <node> and kubectl describe node <node>, a NotReady node or one that no longer exists was lost.",
+			"Once the node controller evicts the pod, its controller creates a replacement on another node, delete the pod to speed this up: kubectl delete pod {pod} -n {namespace}.",
+			"If nodes are lost repeatedly, check the node's kubelet, network and cloud provider events, e.g. spot instance reclaims or autoscaler scale-downs.",
+		},
+	},
+	"OOMKilled": {
+		Cause: "The container used more memory than its limit and was killed by the kernel.",
+		Steps: []string{
+			"Compare the memory limit in resources.limits.memory with the actual usage: kubectl top pod {pod} -n {namespace} --containers.",
+			"Raise the memory limit, or fix the leak or the cache and heap settings (e.g. -Xmx for the JVM) that make the application outgrow it.",
+			"Set a memory request close to the real usage so that the pod is scheduled on a node with enough memory.",
+		},
+	},
+	"CreateContainerConfigError": {
+		Cause: "The container could not be configured, usually because a Secret or ConfigMap, or a key in it, referenced by the pod does not exist.",
+		Steps: []string{
+			"Run kubectl describe pod {pod} -n {namespace} and look for the Failed event naming the missing secret, config map or key.",
+			"List what exists with kubectl get secrets,configmaps -n {namespace} and compare it with the secretKeyRef, configMapKeyRef and envFrom entries of the container.",
+			"If the secret is synced from an external store (e.g. a SecretStore for vault), check that the ExternalSecret is ready: kubectl get externalsecrets -n {namespace}.",
+		},
+	},
+	"FailedScheduling": {
+		Cause: "No node can run the pod, so it stays Pending without any containers started.",
+		Steps: []string{
+			"Read the scheduler's per-node reasons in the FailedScheduling event of kubectl describe pod {pod} -n {namespace}.",
+			"For insufficient cpu or memory, compare the pod's resource requests with kubectl describe nodes (Allocated resources), then lower the requests or add nodes.",
+			"For node affinity, nodeSelector or taint mismatches, compare them with the node labels and taints: kubectl get nodes --show-labels.",
+		},
+	},
+	"ReadinessProbeFailed": {
+		Cause: "The container is running but its readiness probe fails, so it receives no traffic and the rollout does not progress.",
+		Steps: []string{
+			"Read the Unhealthy events in kubectl describe pod {pod} -n {namespace} for the probe's error or status code.",
+			"Check that the probe's path and port match what the application serves: kubectl port-forward {pod} -n {namespace} <port> and request the path locally.",
+			"If the application is only slow to start, raise initialDelaySeconds or add a startupProbe instead of weakening the readiness probe.",
+		},
+	},
+	"LivenessProbeFailed": {
+		Cause: "The container's liveness probe fails, so the kubelet keeps killing and restarting it.",
+		Steps: []string{
+			"Read the Unhealthy events in kubectl describe pod {pod} -n {namespace} for the probe's error or status code.",
+			"Read the logs before the restart with kubectl logs {pod} -n {namespace} -c {container} --previous.",
+			"If the application is slow to start or to answer under load, add a startupProbe or raise timeoutSeconds and failureThreshold.",
+		},
+	},
+	"StartupProbeFailed": {
+		Cause: "The container did not pass its startup probe in time, so the kubelet restarts it before it finished starting.",
+		Steps: []string{
+			"Read the Unhealthy events in kubectl describe pod {pod} -n {namespace} for the probe's error.",
+			"Raise failureThreshold times periodSeconds of the startup probe above the real startup time of the application.",
+		},
+	},
+}
+
+// Explain returns the explanation of reason with the steps filled in for the
+// container of the pod, and false if there is none.
+func Explain(reason, namespace, pod, container string) (Explanation, bool) {
+	if reason == "ErrImagePull" {
+		reason = "ImagePullBackOff"
+	}
+	explanation, ok := explanations[reason]
+	if !ok {
+		return Explanation{}, false
+	}
+	replacer := strings.NewReplacer("{namespace}", namespace, "{pod}", pod, "{container}", container)
+	steps := make([]string, len(explanation.Steps))
+	for i, step := range explanation.Steps {
+		steps[i] = replacer.Replace(step)
+	}
+	return Explanation{Cause: explanation.Cause, Steps: steps}, true
+}
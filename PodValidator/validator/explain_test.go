@@ -0,0 +1,45 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	for _, reason := range []string{"ImagePullBackOff", "ErrImagePull", "CrashLoopBackOff", "OOMKilled", "CreateContainerConfigError", "FailedScheduling", "ReadinessProbeFailed", "LivenessProbeFailed"} {
+		explanation, ok := Explain(reason, "apps", "web-1", "app")
+		if !ok || explanation.Cause == "" || len(explanation.Steps) < 2 {
+			t.Errorf("Explain(%q) = %+v, %v, want a cause and steps", reason, explanation, ok)
+		}
+		for _, step := range explanation.Steps {
+			if strings.Contains(step, "{pod}") || strings.Contains(step, "{namespace}") || strings.Contains(step, "{container}") {
+				t.Errorf("Explain(%q) step %q has unfilled placeholders", reason, step)
+			}
+		}
+	}
+	explanation, _ := Explain("CrashLoopBackOff", "apps", "web-1", "app")
+	if want := "Read the logs of the crashed instance: kubectl logs web-1 -n apps -c app --previous."; explanation.Steps[0] != want {
+		t.Errorf("CrashLoopBackOff step = %q, want %q", explanation.Steps[0], want)
+	}
+	if _, ok := Explain("Completed", "apps", "web-1", "app"); ok {
+		t.Errorf("expected no explanation for an unknown reason")
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	tests := map[string]Category{
+		"ErrImagePull":        CategoryImagePull,
+		"FailedMount":         CategoryConfigError,
+		"StartupProbeFailed":  CategoryProbeFailure,
+		"FailedScheduling":    CategoryPending,
+		"NodeLost":            CategoryNode,
+		"NodeUnhealthy":       CategoryNode,
+		"Evicted":             CategoryNode,
+		"SomethingUnexpected": CategoryUnknown,
+	}
+	for reason, want := range tests {
+		if got := Categorize(reason); got != want {
+			t.Errorf("Categorize(%q) = %v, want %v", reason, got, want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// imageVersions groups the containers of the pods by the image ID they run,
+// in the order they were first seen. Containers whose image was not pulled
+// yet have no image ID and are left out.
+func imageVersions(pods *v1.PodList) []ImageVersion {
+	var versions []ImageVersion
+	index := map[string]int{}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Status.ContainerStatuses {
+			if container.ImageID == "" {
+				continue
+			}
+			key := container.Name + "\x00" + container.ImageID
+			i, ok := index[key]
+			if !ok {
+				i = len(versions)
+				index[key] = i
+				versions = append(versions, ImageVersion{Container: container.Name, Image: container.Image, ImageID: container.ImageID})
+			}
+			versions[i].Pods = append(versions[i].Pods, pod.Name)
+		}
+	}
+	return versions
+}
+
+// MixedImages returns the containers that run more than one image ID across
+// the pods, a sign of an incomplete rollout.
+func (r *Result) MixedImages() []string {
+	var mixed []string
+	count := map[string]int{}
+	for _, version := range r.Images {
+		if count[version.Container]++; count[version.Container] == 2 {
+			mixed = append(mixed, version.Container)
+		}
+	}
+	return mixed
+}
+
+// ImageDigest is the digest part of an image ID such as
+// docker-pullable://registry/web@sha256:..., or the whole ID without one.
+func ImageDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	return strings.TrimPrefix(imageID, "docker://")
+}
+
+// imageReference splits an image into its tag, "latest" if it has neither a
+// tag nor a digest as the container runtime then pulls latest, and its digest.
+func imageReference(image string) (tag, digest string) {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		image, digest = image[:i], image[i+1:]
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:], digest
+	}
+	if digest == "" {
+		return "latest", ""
+	}
+	return "", digest
+}
+
+// pullPolicyDiagnosis warns about combinations of imagePullPolicy and image
+// reference that commonly cause or complicate pull failures. An empty policy
+// is defaulted by the API server and not checked.
+func pullPolicyDiagnosis(spec *v1.Container, nodeName string) []Diagnosis {
+	var diagnosis []Diagnosis
+	tag, digest := imageReference(spec.Image)
+	switch spec.ImagePullPolicy {
+	case v1.PullNever:
+		node := "the node"
+		if nodeName != "" {
+			node = "node " + nodeName
+		}
+		diagnosis = append(diagnosis, Diagnosis{Reason: "ImagePullPolicy", Message: fmt.Sprintf("imagePullPolicy is Never, so image %v is only used if it is already present on %v, load it onto every node or set imagePullPolicy to IfNotPresent", spec.Image, node)})
+	case v1.PullAlways:
+		if digest != "" {
+			diagnosis = append(diagnosis, Diagnosis{Reason: "ImagePullPolicy", Message: fmt.Sprintf("imagePullPolicy is Always for image %v pinned to a digest, which never changes, IfNotPresent would not depend on the registry on every container start", spec.Image)})
+		}
+	}
+	if tag == "latest" && digest == "" {
+		diagnosis = append(diagnosis, Diagnosis{Reason: "ImagePullPolicy", Message: fmt.Sprintf("Image %v uses the mutable latest tag, pods may run different images and a rollout cannot be rolled back to a known image, pin a version tag or digest", spec.Image)})
+	}
+	return diagnosis
+}
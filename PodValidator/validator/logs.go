@@ -0,0 +1,286 @@
+package validator
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogOptions control which container log lines are fetched and reported as
+// errors.
+type LogOptions struct {
+	// Match selects the lines to report, nil reports every line.
+	Match *regexp.Regexp
+	// Exclude drops matching lines, nil excludes nothing.
+	Exclude *regexp.Regexp
+	// MaxLines caps the number of matching lines reported per container.
+	MaxLines int
+	// Context is the number of lines reported before and after each match.
+	Context int
+	// TailLines only fetches the last N lines of the log, 0 fetches all of it.
+	TailLines int64
+	// Since only fetches the lines logged within this duration, 0 fetches
+	// all of them.
+	Since time.Duration
+	// MaxBytes stops reading a log after this many bytes, 0 reads all of it.
+	MaxBytes int64
+	// Timeout bounds fetching the logs of each container, so that an
+	// unreachable kubelet does not stall the diagnosis. 0 means no bound.
+	Timeout time.Duration
+	// Follow streams the log for up to this long after it was fetched, until
+	// MaxLines lines matched, to catch the error of the next crash of a
+	// container that restarts. 0 does not follow.
+	Follow time.Duration
+}
+
+func (l LogOptions) matches(line string) bool {
+	if l.Match != nil && !l.Match.MatchString(line) {
+		return false
+	}
+	return l.Exclude == nil || !l.Exclude.MatchString(line)
+}
+
+// CompilePatterns joins comma-separated keywords or regular expressions into a
+// single case-insensitive regexp, compiled once rather than per log line. An
+// empty list yields nil.
+func CompilePatterns(list string) (*regexp.Regexp, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, "(?:"+pattern+")")
+		}
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	return regexp.Compile("(?i)" + strings.Join(patterns, "|"))
+}
+
+// logContext bounds fetching the log of a container by LogOptions.Timeout.
+// timedOut describes an error of the fetch, telling the per-container timeout
+// from the end of the whole run.
+func (v *Validator) logContext(parent context.Context) (ctx context.Context, cancel context.CancelFunc, timedOut func(error) string) {
+	ctx, cancel = parent, func() {}
+	if v.options.Logs.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, v.options.Logs.Timeout)
+	}
+	timedOut = func(err error) string {
+		if ctx.Err() != nil && parent.Err() == nil {
+			return fmt.Sprintf("log unavailable, fetching it timed out after %v", v.options.Logs.Timeout)
+		}
+		return err.Error()
+	}
+	return ctx, cancel, timedOut
+}
+
+func (v *Validator) getPodlogs(ctx context.Context, podName string, container v1.ContainerStatus) *Logs {
+	defer v.track("fetch logs of pod "+podName, time.Now())
+	logs := &Logs{}
+	parent := ctx
+	ctx, cancel, timedOut := v.logContext(ctx)
+	defer cancel()
+	var podLogs io.ReadCloser
+	var err error
+	if container.RestartCount > 0 {
+		// The crash is recorded in the previous instance; the current one may be healthy or empty.
+		podLogs, err = v.streamLogs(ctx, podName, container.Name, true)
+		if err != nil {
+			logs.PreviousError = timedOut(err)
+			podLogs = nil
+		} else {
+			logs.Previous = true
+		}
+	}
+	if podLogs == nil {
+		podLogs, err = v.streamLogs(ctx, podName, container.Name, false)
+		if err != nil {
+			// Logs are unavailable, e.g. the kubelet is unreachable or the
+			// container never started; report it and move on to the next one.
+			logs.Error = timedOut(err)
+			return logs
+		}
+	}
+	defer podLogs.Close()
+	// A stalled stream does not notice ctx by itself, closing it ends the read.
+	stop := context.AfterFunc(ctx, func() { podLogs.Close() })
+	defer stop()
+	var reader io.Reader = podLogs
+	var limited *limitedReader
+	if v.options.Logs.MaxBytes > 0 {
+		limited = &limitedReader{reader: podLogs, remaining: v.options.Logs.MaxBytes}
+		reader = limited
+	}
+	if logs.Lines, err = scanLogs(bufio.NewReader(reader), v.options.Logs); err != nil {
+		logs.ReadError = timedOut(err)
+		v.logger().Warn("Error reading logs", "pod", podName, "container", container.Name, "error", logs.ReadError)
+	}
+	if limited != nil && limited.truncated {
+		logs.TruncatedAt = v.options.Logs.MaxBytes
+	}
+	if v.options.Logs.Follow > 0 {
+		v.followLogs(parent, podName, container.Name, logs)
+	}
+	return logs
+}
+
+// followLogsRetry is the time to wait before following a log again once its
+// stream ended, e.g. because the container exited and is in back-off.
+const followLogsRetry = 2 * time.Second
+
+// followLogs streams the log of the container for up to LogOptions.Follow,
+// adding new matching lines to logs and logging them as they arrive, until
+// MaxLines lines matched. A crash-looping container ends the stream every
+// time it exits, so the stream is reopened from the time it was last read
+// to catch the next run.
+func (v *Validator) followLogs(ctx context.Context, podName, container string, logs *Logs) {
+	opts := v.options.Logs
+	matched := 0
+	seen := map[string]bool{}
+	for _, line := range logs.Lines {
+		if opts.matches(line) {
+			matched++
+			seen[line] = true
+		}
+	}
+	if matched >= opts.MaxLines {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Follow)
+	defer cancel()
+	v.logger().Info("Following logs for matching lines", "pod", podName, "container", container, "for", opts.Follow)
+	logs.Followed = true
+	since := metav1.Now()
+	for matched < opts.MaxLines {
+		stream, err := v.client.CoreV1().Pods(v.options.Namespace).GetLogs(podName, &v1.PodLogOptions{Container: container, Follow: true, SinceTime: &since}).Stream(ctx)
+		if err == nil {
+			since = metav1.Now()
+			stop := context.AfterFunc(ctx, func() { stream.Close() })
+			reader := bufio.NewReader(stream)
+			for matched < opts.MaxLines {
+				line, _, err := reader.ReadLine()
+				if err != nil {
+					break
+				}
+				if lineStr := string(line); opts.matches(lineStr) && !seen[lineStr] {
+					seen[lineStr] = true
+					matched++
+					logs.Lines = append(logs.Lines, lineStr)
+					v.logger().Info("Matching log line", "pod", podName, "container", container, "line", lineStr)
+				}
+			}
+			stop()
+			stream.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(followLogsRetry):
+		}
+	}
+}
+
+// limitedReader ends the log after remaining bytes like io.LimitReader and
+// records whether there was more to read.
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+	truncated bool
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		var next [1]byte
+		n, _ := r.reader.Read(next[:])
+		r.truncated = r.truncated || n > 0
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
+
+// scanLogs returns the lines matching the log options, together with their
+// context lines. Non-adjacent groups are separated by "--" like grep -C does.
+// A read error stops the scan and is returned with the lines read before it.
+func scanLogs(reader *bufio.Reader, opts LogOptions) ([]string, error) {
+	var lines []string
+	var before []string
+	seenLines := make(map[string]bool)
+	matchCount, afterCount := 0, 0
+	lineNumber, lastEmitted := 0, 0
+	emit := func(lineStr string) {
+		lines = append(lines, lineStr)
+		lastEmitted = lineNumber
+	}
+	for matchCount < opts.MaxLines || afterCount > 0 {
+		line, _, err := reader.ReadLine()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// A broken stream keeps failing, return what was read so far.
+			return lines, err
+		}
+		lineStr := string(line)
+		lineNumber++
+		if matchCount < opts.MaxLines && opts.matches(lineStr) && !seenLines[lineStr] {
+			seenLines[lineStr] = true
+			matchCount++
+			if opts.Context > 0 && lastEmitted > 0 && lineNumber-len(before) > lastEmitted+1 {
+				lines = append(lines, "--")
+			}
+			for _, contextLine := range before {
+				emit(contextLine)
+			}
+			before = before[:0]
+			emit(lineStr)
+			afterCount = opts.Context
+		} else if afterCount > 0 {
+			emit(lineStr)
+			afterCount--
+		} else if opts.Context > 0 {
+			before = append(before, lineStr)
+			if len(before) > opts.Context {
+				before = before[1:]
+			}
+		}
+	}
+	return lines, nil
+}
+
+func (v *Validator) streamLogs(ctx context.Context, podName string, containerName string, previous bool) (io.ReadCloser, error) {
+	logOptions := &v1.PodLogOptions{
+		Container: containerName,
+		Previous:  previous,
+	}
+	if v.options.Logs.TailLines > 0 {
+		logOptions.TailLines = &v.options.Logs.TailLines
+	}
+	if v.options.Logs.MaxBytes > 0 {
+		// Have the kubelet stop too, one byte past the limit tells a
+		// truncated log from one of exactly MaxBytes.
+		limit := v.options.Logs.MaxBytes + 1
+		logOptions.LimitBytes = &limit
+	}
+	if v.options.Logs.Since > 0 {
+		seconds := int64((v.options.Logs.Since + time.Second - 1) / time.Second)
+		logOptions.SinceSeconds = &seconds
+	}
+	var stream io.ReadCloser
+	err := v.retry(ctx, "getting logs", func() (err error) {
+		stream, err = v.client.CoreV1().Pods(v.options.Namespace).GetLogs(podName, logOptions).Stream(ctx)
+		return err
+	})
+	return stream, err
+}
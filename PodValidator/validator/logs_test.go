@@ -0,0 +1,130 @@
+package validator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompilePatterns(t *testing.T) {
+	re, err := CompilePatterns("panic, fatal ,timeout.*exceeded")
+	if err != nil {
+		t.Fatalf("CompilePatterns() error = %v", err)
+	}
+	for line, want := range map[string]bool{
+		"PANIC: runtime error":       true,
+		"level=fatal msg=boom":       true,
+		"timeout of 30s exceeded":    true,
+		"request completed normally": false,
+	} {
+		if got := re.MatchString(line); got != want {
+			t.Errorf("MatchString(%q) = %v, want %v", line, got, want)
+		}
+	}
+
+	if re, err := CompilePatterns(" , "); err != nil || re != nil {
+		t.Errorf("CompilePatterns of an empty list = %v, %v, want nil, nil", re, err)
+	}
+	if _, err := CompilePatterns("a("); err == nil {
+		t.Errorf("expected an error for an invalid regular expression")
+	}
+}
+
+func TestScanLogs(t *testing.T) {
+	match, _ := CompilePatterns("error")
+	exclude, _ := CompilePatterns("datadog")
+	log := strings.Join([]string{
+		"starting",
+		"error: one",
+		"datadog error: ignored",
+		"ok",
+		"ok",
+		"ok",
+		"error: one",
+		"error: two",
+		"done",
+	}, "\n")
+
+	tests := []struct {
+		name string
+		opts LogOptions
+		want []string
+	}{
+		{
+			name: "matches are deduplicated and excluded",
+			opts: LogOptions{Match: match, Exclude: exclude, MaxLines: 10},
+			want: []string{"error: one", "error: two"},
+		},
+		{
+			name: "line cap",
+			opts: LogOptions{Match: match, Exclude: exclude, MaxLines: 1},
+			want: []string{"error: one"},
+		},
+		{
+			name: "context lines",
+			opts: LogOptions{Match: match, Exclude: exclude, MaxLines: 10, Context: 1},
+			want: []string{"starting", "error: one", "datadog error: ignored", "--", "error: one", "error: two", "done"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scanLogs(bufio.NewReader(strings.NewReader(log)), tt.opts)
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scanLogs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// brokenReader returns its data and then fails like a dropped stream.
+type brokenReader struct {
+	data string
+	err  error
+}
+
+func (r *brokenReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestScanLogsReadError(t *testing.T) {
+	streamErr := fmt.Errorf("stream reset: %w", io.ErrUnexpectedEOF)
+	reader := &brokenReader{data: "error: one\n", err: streamErr}
+	got, err := scanLogs(bufio.NewReader(reader), LogOptions{MaxLines: 10})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("scanLogs() error = %v, want the stream error", err)
+	}
+	if want := []string{"error: one"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("scanLogs() = %q, want the lines read before the error %q", got, want)
+	}
+}
+
+func TestScanLogsMaxBytes(t *testing.T) {
+	log := "error: one\nerror: two\nerror: three\n"
+	tests := []struct {
+		maxBytes      int64
+		want          []string
+		wantTruncated bool
+	}{
+		{maxBytes: 16, want: []string{"error: one", "error"}, wantTruncated: true},
+		{maxBytes: int64(len(log)), want: []string{"error: one", "error: two", "error: three"}},
+	}
+	for _, test := range tests {
+		reader := &limitedReader{reader: strings.NewReader(log), remaining: test.maxBytes}
+		got, err := scanLogs(bufio.NewReader(reader), LogOptions{MaxLines: 10})
+		if err != nil {
+			t.Fatalf("scanLogs() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, test.want) || reader.truncated != test.wantTruncated {
+			t.Errorf("MaxBytes %v: scanLogs() = %q, truncated %v, want %q, truncated %v", test.maxBytes, got, reader.truncated, test.want, test.wantTruncated)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package validator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	Appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// ManifestResult holds the findings of the static checks of one workload in
+// a manifest.
+type ManifestResult struct {
+	Kind      string      `json:"kind"`
+	Namespace string      `json:"namespace"`
+	Name      string      `json:"name"`
+	Findings  []Diagnosis `json:"findings,omitempty"`
+}
+
+// manifestObject is a Secret or ConfigMap, with the keys it holds, that is
+// either defined in the manifest or looked up in the cluster.
+type manifestObject struct {
+	found   bool
+	checked bool
+	keys    map[string]bool
+}
+
+type manifestCheck struct {
+	v       *Validator
+	objects map[string]manifestObject
+}
+
+// CheckManifest decodes the YAML or JSON documents read from r and checks the
+// pod templates of the workloads in it for the problems that are otherwise
+// only diagnosed once they are deployed: image pull secrets, secrets and
+// config maps that do not exist and containers without resource limits.
+// References are resolved against the Secrets and ConfigMaps in the manifest
+// and, when the Validator has a client, the cluster.
+func (v *Validator) CheckManifest(ctx context.Context, r io.Reader) ([]ManifestResult, error) {
+	var workloads []runtime.Object
+	check := &manifestCheck{v: v, objects: map[string]manifestObject{}}
+
+	reader := yaml.NewYAMLReader(bufio.NewReader(r))
+	for document := 1; ; document++ {
+		data, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading manifest: %w", err)
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		object, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			v.logger().Warn("Skipping unknown kind in manifest", "document", document, "error", err)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding manifest document %v: %w", document, err)
+		}
+		switch object := object.(type) {
+		case *v1.Secret:
+			keys := map[string]bool{}
+			for key := range object.Data {
+				keys[key] = true
+			}
+			for key := range object.StringData {
+				keys[key] = true
+			}
+			check.objects[objectKey("Secret", v.manifestNamespace(object.Namespace), object.Name)] = manifestObject{found: true, checked: true, keys: keys}
+		case *v1.ConfigMap:
+			keys := map[string]bool{}
+			for key := range object.Data {
+				keys[key] = true
+			}
+			for key := range object.BinaryData {
+				keys[key] = true
+			}
+			check.objects[objectKey("ConfigMap", v.manifestNamespace(object.Namespace), object.Name)] = manifestObject{found: true, checked: true, keys: keys}
+		default:
+			object.GetObjectKind().SetGroupVersionKind(*gvk)
+			workloads = append(workloads, object)
+		}
+	}
+
+	var results []ManifestResult
+	for _, object := range workloads {
+		var meta metav1.ObjectMeta
+		var template v1.PodTemplateSpec
+		switch object := object.(type) {
+		case *Appsv1.Deployment:
+			meta, template = object.ObjectMeta, object.Spec.Template
+		case *Appsv1.StatefulSet:
+			meta, template = object.ObjectMeta, object.Spec.Template
+		case *Appsv1.DaemonSet:
+			meta, template = object.ObjectMeta, object.Spec.Template
+		case *Appsv1.ReplicaSet:
+			meta, template = object.ObjectMeta, object.Spec.Template
+		case *batchv1.Job:
+			meta, template = object.ObjectMeta, object.Spec.Template
+		case *batchv1.CronJob:
+			meta, template = object.ObjectMeta, object.Spec.JobTemplate.Spec.Template
+		case *v1.Pod:
+			meta, template = object.ObjectMeta, v1.PodTemplateSpec{Spec: object.Spec}
+		default:
+			continue
+		}
+		result := ManifestResult{
+			Kind:      object.GetObjectKind().GroupVersionKind().Kind,
+			Namespace: v.manifestNamespace(meta.Namespace),
+			Name:      meta.Name,
+		}
+		result.Findings = check.podSpec(ctx, result.Namespace, template.Spec)
+		categorize(result.Findings)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// manifestNamespace is the namespace of an object without one in the
+// manifest, as kubectl apply would use it.
+func (v *Validator) manifestNamespace(namespace string) string {
+	if namespace != "" {
+		return namespace
+	}
+	if v.options.Namespace != "" {
+		return v.options.Namespace
+	}
+	return metav1.NamespaceDefault
+}
+
+func objectKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+func (c *manifestCheck) podSpec(ctx context.Context, namespace string, spec v1.PodSpec) []Diagnosis {
+	var findings []Diagnosis
+	for _, secret := range spec.ImagePullSecrets {
+		object := c.lookup(ctx, "Secret", namespace, secret.Name)
+		if !object.checked {
+			findings = append(findings, Diagnosis{Reason: "ImagePullSecret", Message: fmt.Sprintf("Secret %v is not defined in the manifest and could not be checked in namespace %v, make sure it exists before deploying", secret.Name, namespace)})
+		} else if !object.found {
+			findings = append(findings, Diagnosis{Reason: "ImagePullSecret", Message: fmt.Sprintf("Secret %v does not exist in namespace %v, please add it", secret.Name, namespace)})
+		}
+	}
+
+	containers := append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil && (ref.Optional == nil || !*ref.Optional) {
+				findings = append(findings, c.keyRef(ctx, namespace, container.Name, env.Name, "Secret", ref.Name, ref.Key)...)
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil && (ref.Optional == nil || !*ref.Optional) {
+				findings = append(findings, c.keyRef(ctx, namespace, container.Name, env.Name, "ConfigMap", ref.Name, ref.Key)...)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if ref := envFrom.SecretRef; ref != nil && (ref.Optional == nil || !*ref.Optional) {
+				findings = append(findings, c.keyRef(ctx, namespace, container.Name, "envFrom", "Secret", ref.Name, "")...)
+			}
+			if ref := envFrom.ConfigMapRef; ref != nil && (ref.Optional == nil || !*ref.Optional) {
+				findings = append(findings, c.keyRef(ctx, namespace, container.Name, "envFrom", "ConfigMap", ref.Name, "")...)
+			}
+		}
+
+		var missing []string
+		for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+			if _, ok := container.Resources.Limits[name]; !ok {
+				missing = append(missing, string(name))
+			}
+		}
+		if len(missing) > 0 {
+			findings = append(findings, Diagnosis{Reason: "ResourceLimits", Message: fmt.Sprintf("Container %v has no %v limit", container.Name, strings.Join(missing, " or "))})
+		}
+	}
+	return findings
+}
+
+// keyRef reports a reference from an env var to a Secret or ConfigMap, or to
+// a key in it, that does not exist. References that could not be checked
+// are not reported.
+func (c *manifestCheck) keyRef(ctx context.Context, namespace, container, env, kind, name, key string) []Diagnosis {
+	object := c.lookup(ctx, kind, namespace, name)
+	reason := "CreateContainerConfigError"
+	switch {
+	case !object.checked:
+		return nil
+	case !object.found:
+		return []Diagnosis{{Reason: reason, Message: fmt.Sprintf("Container %v env %v references %v %v which does not exist in namespace %v", container, env, kind, name, namespace)}}
+	case key != "" && !object.keys[key]:
+		return []Diagnosis{{Reason: reason, Message: fmt.Sprintf("Container %v env %v references key %v which is not in %v %v", container, env, key, kind, name)}}
+	}
+	return nil
+}
+
+// lookup returns the Secret or ConfigMap from the manifest, or from the
+// cluster if it is not defined in the manifest.
+func (c *manifestCheck) lookup(ctx context.Context, kind, namespace, name string) manifestObject {
+	key := objectKey(kind, namespace, name)
+	if object, ok := c.objects[key]; ok {
+		return object
+	}
+	var object manifestObject
+	if c.v.client != nil {
+		keys := map[string]bool{}
+		var err error
+		if kind == "Secret" {
+			var secret *v1.Secret
+			err = c.v.retry(ctx, "getting secret", func() (err error) {
+				secret, err = c.v.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+				return err
+			})
+			if err == nil {
+				for key := range secret.Data {
+					keys[key] = true
+				}
+			}
+		} else {
+			var configMap *v1.ConfigMap
+			err = c.v.retry(ctx, "getting config map", func() (err error) {
+				configMap, err = c.v.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+				return err
+			})
+			if err == nil {
+				for key := range configMap.Data {
+					keys[key] = true
+				}
+				for key := range configMap.BinaryData {
+					keys[key] = true
+				}
+			}
+		}
+		switch {
+		case err == nil:
+			object = manifestObject{found: true, checked: true, keys: keys}
+		case apierrors.IsNotFound(err):
+			object = manifestObject{checked: true}
+		default:
+			c.v.logger().Warn(fmt.Sprintf("Could not check %v %v", kind, name), "namespace", namespace, "error", err)
+		}
+	}
+	c.objects[key] = object
+	return object
+}
+
+// ReadDeployment decodes the one Deployment in the YAML or JSON documents
+// read from r and returns it with its pod selector, so that its pods can be
+// validated with a Selector without permission to get the Deployment.
+func ReadDeployment(r io.Reader) (*Appsv1.Deployment, string, error) {
+	var deployments []*Appsv1.Deployment
+	reader := yaml.NewYAMLReader(bufio.NewReader(r))
+	for document := 1; ; document++ {
+		data, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("error reading manifest: %w", err)
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		object, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+		if err != nil {
+			return nil, "", fmt.Errorf("error decoding manifest document %v: %w", document, err)
+		}
+		deployment, ok := object.(*Appsv1.Deployment)
+		if !ok {
+			return nil, "", fmt.Errorf("manifest document %v is a %v, not a Deployment", document, gvk.Kind)
+		}
+		deployments = append(deployments, deployment)
+	}
+	if len(deployments) != 1 {
+		return nil, "", fmt.Errorf("the manifest must contain one Deployment, it has %v", len(deployments))
+	}
+	deployment := deployments[0]
+	if deployment.Spec.Selector == nil {
+		return nil, "", fmt.Errorf("the Deployment %v has no selector", deployment.Name)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid selector of Deployment %v: %w", deployment.Name, err)
+	}
+	if selector.Empty() {
+		return nil, "", fmt.Errorf("the selector of Deployment %v is empty and would match every pod", deployment.Name)
+	}
+	return deployment, selector.String(), nil
+}
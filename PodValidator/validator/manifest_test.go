@@ -0,0 +1,128 @@
+package validator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testManifest = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  LEVEL: debug
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels: {app: web}
+  template:
+    metadata:
+      labels: {app: web}
+    spec:
+      imagePullSecrets: [{name: registry}]
+      containers:
+      - name: app
+        image: registry.example.com/web:1.0
+        resources:
+          limits: {memory: 128Mi}
+        env:
+        - name: LEVEL
+          valueFrom: {configMapKeyRef: {name: settings, key: LEVEL}}
+        - name: MODE
+          valueFrom: {configMapKeyRef: {name: settings, key: MODE}}
+        - name: PASSWORD
+          valueFrom: {secretKeyRef: {name: db, key: password}}
+`
+
+func TestCheckManifest(t *testing.T) {
+	tests := []struct {
+		name   string
+		client kubernetes.Interface
+		want   []Diagnosis
+	}{
+		{
+			name: "without cluster",
+			want: []Diagnosis{
+				{Reason: "ImagePullSecret", Category: CategoryImagePull, Message: "Secret registry is not defined in the manifest and could not be checked in namespace apps, make sure it exists before deploying"},
+				{Reason: "CreateContainerConfigError", Category: CategoryConfigError, Message: "Container app env MODE references key MODE which is not in ConfigMap settings"},
+				{Reason: "ResourceLimits", Category: CategoryConfigError, Message: "Container app has no cpu limit"},
+			},
+		},
+		{
+			name: "with cluster",
+			client: fake.NewSimpleClientset(&v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: testNamespace},
+			}),
+			want: []Diagnosis{
+				{Reason: "CreateContainerConfigError", Category: CategoryConfigError, Message: "Container app env MODE references key MODE which is not in ConfigMap settings"},
+				{Reason: "CreateContainerConfigError", Category: CategoryConfigError, Message: "Container app env PASSWORD references Secret db which does not exist in namespace apps"},
+				{Reason: "ResourceLimits", Category: CategoryConfigError, Message: "Container app has no cpu limit"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := New(test.client, Options{Namespace: testNamespace}).CheckManifest(context.Background(), strings.NewReader(testManifest))
+			if err != nil {
+				t.Fatalf("CheckManifest() error = %v", err)
+			}
+			if len(results) != 1 || results[0].Kind != "Deployment" || results[0].Name != "web" {
+				t.Fatalf("expected one result for Deployment web, got %+v", results)
+			}
+			if !reflect.DeepEqual(results[0].Findings, test.want) {
+				t.Errorf("findings = %+v, want %+v", results[0].Findings, test.want)
+			}
+		})
+	}
+}
+
+func TestReadDeployment(t *testing.T) {
+	deployment := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: apps
+spec:
+  selector:
+    matchLabels: {app: web, tier: frontend}
+`
+	tests := []struct {
+		manifest string
+		selector string
+		err      string
+	}{
+		{manifest: deployment, selector: "app=web,tier=frontend"},
+		{manifest: testManifest, err: "is a ConfigMap, not a Deployment"},
+		{manifest: deployment + "---\n" + deployment, err: "must contain one Deployment, it has 2"},
+		{manifest: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n", err: "has no selector"},
+		{manifest: "", err: "must contain one Deployment, it has 0"},
+	}
+	for _, tt := range tests {
+		got, selector, err := ReadDeployment(strings.NewReader(tt.manifest))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ReadDeployment() error = %v, want it to contain %q", err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ReadDeployment() error = %v", err)
+		}
+		if got.Name != "web" || got.Namespace != "apps" || selector != tt.selector {
+			t.Errorf("ReadDeployment() = %v/%v, %q, want apps/web, %q", got.Namespace, got.Name, selector, tt.selector)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// mentionsMount reports whether a runtime or event message is about volumes.
+func mentionsMount(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "mount") || strings.Contains(message, "volume") || strings.Contains(message, "subpath")
+}
+
+// mountsNote lists the volume mounts of a container with the type of their
+// volume, and names the mount the message is about when it can tell.
+func mountsNote(pod v1.Pod, containerName, message string) string {
+	spec := specContainer(pod, containerName)
+	if spec == nil || len(spec.VolumeMounts) == 0 {
+		return "the container has no volume mounts"
+	}
+	volumes := map[string]v1.Volume{}
+	for _, volume := range pod.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+
+	var mounts []string
+	var failing string
+	for _, mount := range spec.VolumeMounts {
+		volume, ok := volumes[mount.Name]
+		kind := "volume not defined in the pod"
+		if ok {
+			kind = volumeType(volume)
+		}
+		mounts = append(mounts, fmt.Sprintf("%v at %v (%v)", mount.Name, mount.MountPath, kind))
+		if failing == "" && (strings.Contains(message, `"`+mount.Name+`"`) || strings.Contains(message, mount.MountPath)) {
+			failing = fmt.Sprintf(", the failing one is %v (%v)", mount.Name, kind)
+		}
+	}
+	return fmt.Sprintf("volume mounts: %v%v", strings.Join(mounts, ", "), failing)
+}
+
+func volumeType(volume v1.Volume) string {
+	source := volume.VolumeSource
+	switch {
+	case source.HostPath != nil:
+		return "hostPath " + source.HostPath.Path
+	case source.EmptyDir != nil:
+		return "emptyDir"
+	case source.PersistentVolumeClaim != nil:
+		return "persistentVolumeClaim " + source.PersistentVolumeClaim.ClaimName
+	case source.ConfigMap != nil:
+		return "configMap " + source.ConfigMap.Name
+	case source.Secret != nil:
+		return "secret " + source.Secret.SecretName
+	case source.Projected != nil:
+		return "projected"
+	case source.CSI != nil:
+		return "csi " + source.CSI.Driver
+	case source.NFS != nil:
+		return "nfs " + source.NFS.Server + ":" + source.NFS.Path
+	}
+	return "other"
+}
@@ -0,0 +1,95 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nodePressureConditions are the node conditions that are a problem when
+// they are true.
+var nodePressureConditions = []v1.NodeConditionType{
+	v1.NodeMemoryPressure,
+	v1.NodeDiskPressure,
+	v1.NodePIDPressure,
+	v1.NodeNetworkUnavailable,
+}
+
+// diagnoseNodes checks the nodes the failing pods are scheduled on, adds a
+// NodeUnhealthy diagnosis to the pods on a node with problems and returns
+// those nodes. A failing pod is sometimes only the symptom of its node.
+func (v *Validator) diagnoseNodes(ctx context.Context, pods []PodResult) []NodeProblem {
+	var nodes []NodeProblem
+	index := map[string]int{}
+	checked := map[string]bool{}
+	for i, pod := range pods {
+		if pod.Ready || pod.Completed || pod.Node == "" {
+			continue
+		}
+		if !checked[pod.Node] {
+			checked[pod.Node] = true
+			conditions, err := v.nodeConditions(ctx, pod.Node)
+			if err != nil {
+				v.logger().Warn("Could not check the node of the failing pods", "node", pod.Node, "error", err)
+			}
+			if len(conditions) > 0 {
+				index[pod.Node] = len(nodes)
+				nodes = append(nodes, NodeProblem{Name: pod.Node, Conditions: conditions})
+			}
+		}
+		j, ok := index[pod.Node]
+		if !ok {
+			continue
+		}
+		nodes[j].Pods = append(nodes[j].Pods, pod.Name)
+		pods[i].Diagnosis = append(pods[i].Diagnosis, Diagnosis{
+			Reason:  "NodeUnhealthy",
+			Message: fmt.Sprintf("Pod runs on node %v which is unhealthy: %v, check it with kubectl describe node %v", pod.Node, strings.Join(nodes[j].Conditions, "; "), pod.Node),
+		})
+		// The pod was classified before its node was checked.
+		categorize(pods[i].Diagnosis)
+	}
+	return nodes
+}
+
+// nodeConditions returns the problems of a node as "Condition: message", e.g.
+// "MemoryPressure: kubelet has insufficient memory available".
+func (v *Validator) nodeConditions(ctx context.Context, name string) ([]string, error) {
+	var node *v1.Node
+	err := v.retry(ctx, "getting node", func() (err error) {
+		node, err = v.client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, condition := range node.Status.Conditions {
+		problem := false
+		if condition.Type == v1.NodeReady {
+			problem = condition.Status != v1.ConditionTrue
+			condition.Type = "NotReady"
+		}
+		for _, pressure := range nodePressureConditions {
+			if condition.Type == pressure && condition.Status == v1.ConditionTrue {
+				problem = true
+			}
+		}
+		if !problem {
+			continue
+		}
+		if condition.Message != "" {
+			problems = append(problems, fmt.Sprintf("%v: %v", condition.Type, condition.Message))
+		} else {
+			problems = append(problems, string(condition.Type))
+		}
+	}
+	if node.Spec.Unschedulable {
+		problems = append(problems, "SchedulingDisabled: the node is cordoned")
+	}
+	return problems, nil
+}
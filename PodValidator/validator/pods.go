@@ -0,0 +1,651 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxConcurrentContainers bounds the containers of a pod diagnosed at once.
+const maxConcurrentContainers = 4
+
+// defaultConcurrentPods bounds the pods diagnosed at once when
+// Options.MaxConcurrentPods is not set.
+const defaultConcurrentPods = 10
+
+// diagnosePods diagnoses up to Options.MaxConcurrentPods pods at once. The
+// results are stored by index, so they keep the order of the pod list and are
+// only printed once every pod is diagnosed. With Options.FailFast only the
+// failing pod that comes first in the pod list is returned: a failure stops
+// the diagnosis of the pods after it, those before it are still diagnosed.
+func (v *Validator) diagnosePods(ctx context.Context, pods *v1.PodList) []PodResult {
+	if len(pods.Items) == 0 {
+		return nil
+	}
+	concurrency := v.options.MaxConcurrentPods
+	if concurrency <= 0 {
+		concurrency = defaultConcurrentPods
+	}
+	// With FailFast a failing pod cancels the pods after it in the list,
+	// first is the lowest index of a failing pod.
+	cancels := make([]context.CancelFunc, len(pods.Items))
+	podCtxs := make([]context.Context, len(pods.Items))
+	for i := range pods.Items {
+		podCtxs[i], cancels[i] = context.WithCancel(ctx)
+		defer cancels[i]()
+	}
+	var mu sync.Mutex
+	first := -1
+
+	results := make([]PodResult, len(pods.Items))
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, concurrency)
+	for i, pod := range pods.Items {
+		wg.Add(1)
+		go func(i int, pod v1.Pod) {
+			defer wg.Done()
+			podCtx := podCtxs[i]
+			select {
+			case limit <- struct{}{}:
+			case <-podCtx.Done():
+				return
+			}
+			defer func() { <-limit }()
+			if podCtx.Err() != nil {
+				return
+			}
+			result := v.diagnosePod(podCtx, pod)
+			if podCtx.Err() != nil && ctx.Err() == nil {
+				// Cancelled by a failing pod before it, the diagnosis is incomplete.
+				return
+			}
+			results[i] = result
+			if v.options.FailFast && !result.Ready && !result.Completed {
+				mu.Lock()
+				if first < 0 || i < first {
+					first = i
+					for _, cancel := range cancels[i+1:] {
+						cancel()
+					}
+				}
+				mu.Unlock()
+			}
+		}(i, pod)
+	}
+	wg.Wait()
+	if first >= 0 {
+		return results[first : first+1]
+	}
+	return results
+}
+
+// groupErrors groups the failed containers of the pods by their signature,
+// the name, diagnosed reasons and log lines, and returns the groups shared by
+// more than one pod in the order they were first seen.
+func groupErrors(pods []PodResult) []ErrorGroup {
+	var groups []ErrorGroup
+	index := map[string]int{}
+	for _, pod := range pods {
+		for _, container := range pod.Containers {
+			if container.Ready && container.State.Running != nil {
+				continue
+			}
+			reasons := []string{container.Reason}
+			for _, diagnosis := range container.Diagnosis {
+				reasons = append(reasons, diagnosis.Reason)
+			}
+			signature := container.Name + "\x00" + strings.Join(reasons, "\x00")
+			if container.Logs != nil {
+				signature += "\x00" + strings.Join(container.Logs.Lines, "\n")
+			}
+			i, ok := index[signature]
+			if !ok {
+				i = len(groups)
+				index[signature] = i
+				reason := container.Reason
+				if reason == "" && len(container.Diagnosis) > 0 {
+					reason = container.Diagnosis[0].Reason
+				}
+				groups = append(groups, ErrorGroup{Container: container.Name, Reason: reason})
+			}
+			groups[i].Pods = append(groups[i].Pods, pod.Name)
+		}
+	}
+
+	shared := groups[:0]
+	for _, group := range groups {
+		if len(group.Pods) > 1 {
+			shared = append(shared, group)
+		}
+	}
+	return shared
+}
+
+// selected reports whether the container is among Options.Containers.
+func (v *Validator) selected(container string) bool {
+	if len(v.options.Containers) == 0 {
+		return true
+	}
+	for _, name := range v.options.Containers {
+		if name == container {
+			return true
+		}
+	}
+	return false
+}
+
+// unselectedContainers returns the names in Options.Containers that are not a
+// container or init container of any of the pods, probably misspelled.
+func (v *Validator) unselectedContainers(pods *v1.PodList) []string {
+	var unknown []string
+	for _, name := range v.options.Containers {
+		found := false
+		for _, pod := range pods.Items {
+			if specContainer(pod, name) != nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+// excludedNotReady returns the containers of the pod left out by
+// Options.Containers that are not ready. They still keep the pod from being
+// ready.
+func (v *Validator) excludedNotReady(pod v1.Pod) []string {
+	var names []string
+	for _, container := range pod.Status.InitContainerStatuses {
+		if !v.selected(container.Name) && isSidecar(pod, container.Name) && !container.Ready {
+			names = append(names, container.Name)
+		}
+	}
+	for _, container := range pod.Status.ContainerStatuses {
+		if !v.selected(container.Name) && !container.Ready {
+			names = append(names, container.Name)
+		}
+	}
+	return names
+}
+
+func (v *Validator) diagnosePod(ctx context.Context, pod v1.Pod) PodResult {
+	result := PodResult{Name: pod.Name, Phase: pod.Status.Phase, Node: pod.Spec.NodeName, Ready: podReady(pod)}
+	if pod.Status.Phase == v1.PodSucceeded {
+		result.Completed = true
+		return result
+	}
+	if !result.Ready {
+		events, err := v.warningEvents(ctx, pod)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("error listing events: %v", err))
+		}
+		result.Events = events
+	}
+	if diagnosis, ok := evictionDiagnosis(pod); ok {
+		result.Diagnosis = append(result.Diagnosis, diagnosis)
+	} else if nodeLost(pod) {
+		result.Diagnosis = append(result.Diagnosis, Diagnosis{
+			Reason:  "NodeLost",
+			Message: fmt.Sprintf("Node %v of the pod was lost or stopped reporting, the state and logs of its containers are gone with it, check the node with kubectl get node %v and kubectl describe node %v", pod.Spec.NodeName, pod.Spec.NodeName, pod.Spec.NodeName),
+		})
+	}
+	if excluded := v.excludedNotReady(pod); !result.Ready && len(excluded) > 0 {
+		result.Diagnosis = append(result.Diagnosis, Diagnosis{
+			Reason:  "ExcludedContainerNotReady",
+			Message: fmt.Sprintf("Containers %v are not ready and keep the pod from being ready, they are not diagnosed as they are not among the selected containers", strings.Join(excluded, ", ")),
+		})
+	}
+	if pod.Status.Phase == v1.PodPending {
+		if diagnosis, ok := schedulingDiagnosis(pod, result.Events); ok {
+			result.Diagnosis = append(result.Diagnosis, diagnosis)
+		}
+		diagnosis, err := v.claimDiagnosis(ctx, pod)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		result.Diagnosis = append(result.Diagnosis, diagnosis...)
+	}
+	// Init containers run first and block the main containers until they
+	// succeed, so they are reported first. Completed ones are not reported,
+	// nor are native sidecars, init containers that keep running, once they
+	// are running and ready.
+	var containers []v1.ContainerStatus
+	var sidecars []bool
+	initContainers := 0
+	for _, container := range pod.Status.InitContainerStatuses {
+		sidecar := isSidecar(pod, container.Name)
+		if sidecar && container.State.Running != nil && container.Ready || !v.selected(container.Name) {
+			continue
+		}
+		if terminated := container.State.Terminated; sidecar || terminated == nil || terminated.ExitCode != 0 {
+			containers = append(containers, container)
+			sidecars = append(sidecars, sidecar)
+			initContainers++
+		}
+	}
+	for _, container := range pod.Status.ContainerStatuses {
+		if v.selected(container.Name) {
+			containers = append(containers, container)
+		}
+	}
+
+	// Fetching logs dominates, so containers are diagnosed concurrently and
+	// stored by index to keep the order of the pod status.
+	result.Containers = make([]ContainerResult, len(containers))
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, maxConcurrentContainers)
+	for i, container := range containers {
+		wg.Add(1)
+		go func(i int, container v1.ContainerStatus) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			containerResult := v.diagnoseContainer(ctx, pod, container, result.Events)
+			if i < initContainers && sidecars[i] {
+				containerResult.Init, containerResult.Sidecar = true, true
+				containerResult.diagnose("SidecarNotReady", sidecarNote(container))
+			} else if i < initContainers {
+				containerResult.Init = true
+				if terminated := container.State.Terminated; terminated != nil {
+					containerResult.diagnose("InitContainerFailed", fmt.Sprintf("Init container exited with code %v (%v), the main containers will not start until it succeeds", terminated.ExitCode, terminated.Reason))
+				}
+			}
+			result.Containers[i] = containerResult
+		}(i, container)
+	}
+	wg.Wait()
+	classify(&result, pod.Namespace)
+	return result
+}
+
+// schedulingDiagnosis explains why a pending pod has not been scheduled,
+// preferring the most recent FailedScheduling event over the condition
+// message since it carries the scheduler's per-node reasons.
+func schedulingDiagnosis(pod v1.Pod, events []Event) (Diagnosis, bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != v1.PodScheduled {
+			continue
+		}
+		if condition.Status != v1.ConditionFalse {
+			return Diagnosis{}, false
+		}
+		message := condition.Message
+		for i := len(events) - 1; i >= 0; i-- {
+			if events[i].Reason == "FailedScheduling" {
+				message = events[i].Message
+				break
+			}
+		}
+		if message == "" {
+			message = condition.Reason
+		}
+		return Diagnosis{
+			Reason:  "FailedScheduling",
+			Message: fmt.Sprintf("Pod cannot be scheduled: %v, check the resource requests, nodeSelector, affinity and tolerations in the pod spec against the available nodes", message),
+		}, true
+	}
+	if len(pod.Status.ContainerStatuses) > 0 || pod.Spec.NodeName != "" {
+		return Diagnosis{}, false
+	}
+	return Diagnosis{
+		Reason:  "FailedScheduling",
+		Message: "Pod is pending and has not been picked up by the scheduler yet",
+	}, true
+}
+
+func (v *Validator) diagnoseContainer(ctx context.Context, pod v1.Pod, container v1.ContainerStatus, events []Event) ContainerResult {
+	result := ContainerResult{Name: container.Name, Ready: container.Ready, RestartCount: container.RestartCount, Image: container.Image, ImageID: container.ImageID, State: container.State}
+	if spec := specContainer(pod, container.Name); spec != nil {
+		result.Resources = containerResources(spec)
+	}
+	if container.State.Running != nil && container.Ready {
+		return result
+	}
+	result.Termination = containerTermination(container)
+
+	result.Diagnosis = append(result.Diagnosis, probeDiagnosis(pod, container, events)...)
+
+	if terminated := oomKilledState(container); terminated != nil {
+		result.diagnose("OOMKilled", oomKilledNote(pod, container.Name, terminated))
+	}
+	if container.State.Waiting == nil {
+		// The logs of a lost node cannot be fetched, trying only times out.
+		if !nodeLost(pod) {
+			result.Logs = v.getPodlogs(ctx, pod.Name, container)
+		}
+		return result
+	}
+
+	result.Reason = container.State.Waiting.Reason
+	switch container.State.Waiting.Reason {
+	case "ImagePullBackOff", "ErrImagePull":
+		spec := specContainer(pod, container.Name)
+		if len(pod.Spec.ImagePullSecrets) == 0 {
+			result.diagnose("ImagePullBackOff", fmt.Sprintf("Image pull failed without any imagePullSecrets configured, check that the image name %q is correct and its registry is public and reachable from the node", container.Image))
+		} else {
+			image := container.Image
+			if spec != nil {
+				image = spec.Image
+			}
+			result.Diagnosis = append(result.Diagnosis, v.pullSecretDiagnosis(ctx, pod, image)...)
+		}
+		if spec != nil {
+			result.Diagnosis = append(result.Diagnosis, pullPolicyDiagnosis(spec, pod.Spec.NodeName)...)
+		}
+	case "ErrImageNeverPull":
+		if spec := specContainer(pod, container.Name); spec != nil {
+			result.Diagnosis = append(result.Diagnosis, pullPolicyDiagnosis(spec, pod.Spec.NodeName)...)
+		} else {
+			result.diagnose("ImagePullPolicy", fmt.Sprintf("imagePullPolicy is Never and image %v is not present on the node", container.Image))
+		}
+	case "CreateContainerConfigError":
+		if strings.Contains(container.State.Waiting.Message, "secret") {
+			result.diagnose("CreateContainerConfigError", "Check if the env block in deployment yaml has correct \"secretKeyRef\", also see the \"SecretStore\" if the secret is from vault")
+		} else {
+			result.diagnose("CreateContainerConfigError", "Check if the env block in deployment yaml has correct \"configMapKeyRef\" to the volume mount")
+		}
+	case "CrashLoopBackOff":
+		note := fmt.Sprintf("Container has restarted %v times", container.RestartCount)
+		if lastState := container.LastTerminationState.Terminated; lastState != nil {
+			note = fmt.Sprintf("%v, last exit code %v (%v)", note, lastState.ExitCode, lastState.Reason)
+		}
+		result.diagnose("CrashLoopBackOff", note)
+		result.Logs = v.getPodlogs(ctx, pod.Name, container)
+	case "CreateContainerError":
+		note := fmt.Sprintf("Container could not be created: %v", container.State.Waiting.Message)
+		if mentionsMount(container.State.Waiting.Message) {
+			note = fmt.Sprintf("%v, %v", note, mountsNote(pod, container.Name, container.State.Waiting.Message))
+		} else if spec := specContainer(pod, container.Name); spec != nil && (len(spec.Command) > 0 || len(spec.Args) > 0) {
+			note = fmt.Sprintf("%v, check the command %q and args %q of the container", note, spec.Command, spec.Args)
+		} else {
+			note += ", check the entrypoint of the image or set \"command\" in the deployment yaml"
+		}
+		result.diagnose("CreateContainerError", note)
+	case "RunContainerError":
+		message := container.State.Waiting.Message
+		if message == "" && container.LastTerminationState.Terminated != nil {
+			message = container.LastTerminationState.Terminated.Message
+		}
+		note := fmt.Sprintf("Container could not be started: %v", message)
+		if mentionsMount(message) {
+			note = fmt.Sprintf("%v, %v", note, mountsNote(pod, container.Name, message))
+		} else {
+			note += ", check the command, args and entrypoint of the container"
+		}
+		result.diagnose("RunContainerError", note)
+	case "ContainerCreating":
+		// Volumes are set up before the container is created, their
+		// failures are only recorded as events.
+		for i := len(events) - 1; i >= 0; i-- {
+			if reason := events[i].Reason; reason == "FailedMount" || reason == "FailedAttachVolume" {
+				result.diagnose(reason, fmt.Sprintf("%v, %v", events[i].Message, mountsNote(pod, container.Name, events[i].Message)))
+				break
+			}
+		}
+		if len(result.Diagnosis) == 0 {
+			result.Logs = v.getPodlogs(ctx, pod.Name, container)
+		}
+	case "PodInitializing":
+		result.diagnose("PodInitializing", "Container is waiting for the init containers of the pod to complete")
+	default:
+		result.Logs = v.getPodlogs(ctx, pod.Name, container)
+	}
+	return result
+}
+
+// pullSecretDiagnosis checks every imagePullSecret of the pod, reporting the
+// missing ones and whether any of the present ones has credentials for the
+// registry of the image. The real problem may be any of them.
+func (v *Validator) pullSecretDiagnosis(ctx context.Context, pod v1.Pod, image string) []Diagnosis {
+	var diagnosis []Diagnosis
+	var present, covering []*v1.Secret
+	registry := imageRegistry(image)
+	for _, reference := range pod.Spec.ImagePullSecrets {
+		var secret *v1.Secret
+		err := v.retry(ctx, "getting secret", func() (err error) {
+			secret, err = v.client.CoreV1().Secrets(pod.Namespace).Get(ctx, reference.Name, metav1.GetOptions{})
+			return err
+		})
+		if err != nil {
+			diagnosis = append(diagnosis, Diagnosis{Reason: "ImagePullBackOff", Message: fmt.Sprintf("Error getting secret %v: %v in namspace %v, please add them", reference.Name, err, pod.Namespace)})
+			continue
+		}
+		present = append(present, secret)
+		if hosts, ok := registryHosts(secret); ok && containsString(hosts, registry) {
+			covering = append(covering, secret)
+		}
+	}
+
+	for _, secret := range covering {
+		diagnosis = append(diagnosis, Diagnosis{Reason: "ImagePullBackOff", Message: fmt.Sprintf("Secret %v is present in namespace %v and has credentials for %v, this error could be due to expired or wrong values in the secret or a wrong image name %v", secret.Name, pod.Namespace, registry, image)})
+	}
+	if len(covering) > 0 {
+		return diagnosis
+	}
+	for _, secret := range present {
+		if hosts, ok := registryHosts(secret); ok {
+			diagnosis = append(diagnosis, Diagnosis{Reason: "ImagePullBackOff", Message: fmt.Sprintf("Secret %v only has credentials for %v, but image %v is pulled from %v, add credentials for %v to the secret", secret.Name, strings.Join(hosts, ", "), image, registry, registry)})
+		} else {
+			diagnosis = append(diagnosis, Diagnosis{Reason: "ImagePullBackOff", Message: fmt.Sprintf("Secret %v is present in namespace %v, this error could be due to expired or wrong values in the secret", secret.Name, pod.Namespace)})
+		}
+	}
+	return diagnosis
+}
+
+func (c *ContainerResult) diagnose(reason string, message string) {
+	c.Diagnosis = append(c.Diagnosis, Diagnosis{Reason: reason, Message: message})
+}
+
+// nodeLost reports whether the node of the pod went away: the node controller
+// marks its pods NodeLost, and containers whose state the kubelet no longer
+// reports are terminated as ContainerStatusUnknown.
+func nodeLost(pod v1.Pod) bool {
+	if pod.Status.Reason == "NodeLost" {
+		return true
+	}
+	if pod.Status.Reason == "Evicted" {
+		return false
+	}
+	for _, containers := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, container := range containers {
+			if terminated := container.State.Terminated; terminated != nil && terminated.Reason == "ContainerStatusUnknown" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evictedResource finds the resource the node ran low on in the message of
+// an eviction, e.g. "The node was low on resource: memory. ...".
+var evictedResource = regexp.MustCompile(`low on resource: ([\w-]+)`)
+
+// evictionDiagnosis reports a pod the kubelet evicted under node pressure,
+// which is left in the Failed phase with reason Evicted.
+func evictionDiagnosis(pod v1.Pod) (Diagnosis, bool) {
+	if pod.Status.Phase != v1.PodFailed || pod.Status.Reason != "Evicted" {
+		return Diagnosis{}, false
+	}
+	cause := "under node pressure"
+	if match := evictedResource.FindStringSubmatch(pod.Status.Message); match != nil {
+		cause = "because it was low on " + match[1]
+	}
+	message := fmt.Sprintf("Pod was evicted from node %v %v", pod.Spec.NodeName, cause)
+	if pod.Status.Message != "" {
+		message += ": " + strings.TrimSuffix(strings.TrimSpace(pod.Status.Message), ".")
+	}
+	message += fmt.Sprintf(", check the resource requests of its containers against their usage and the capacity of the node with kubectl describe node %v", pod.Spec.NodeName)
+	return Diagnosis{Reason: "Evicted", Message: message}, true
+}
+
+// isSidecar reports whether the init container name is a native sidecar,
+// one with restartPolicy Always that keeps running next to the main
+// containers.
+func isSidecar(pod v1.Pod, name string) bool {
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name == name {
+			return container.RestartPolicy != nil && *container.RestartPolicy == v1.ContainerRestartPolicyAlways
+		}
+	}
+	return false
+}
+
+func sidecarNote(container v1.ContainerStatus) string {
+	state := "running but not ready"
+	switch {
+	case container.State.Waiting != nil:
+		state = container.State.Waiting.Reason
+	case container.State.Terminated != nil:
+		state = fmt.Sprintf("terminated with exit code %v", container.State.Terminated.ExitCode)
+	}
+	return fmt.Sprintf("Sidecar container %v (an init container with restartPolicy Always) is %v after %v restarts, the main containers do not start until it has started, so fix the sidecar first", container.Name, state, container.RestartCount)
+}
+
+func specContainer(pod v1.Pod, name string) *v1.Container {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i]
+		}
+	}
+	for i := range pod.Spec.InitContainers {
+		if pod.Spec.InitContainers[i].Name == name {
+			return &pod.Spec.InitContainers[i]
+		}
+	}
+	return nil
+}
+
+func containerResources(spec *v1.Container) *Resources {
+	resources := &Resources{}
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		if quantity, ok := spec.Resources.Requests[name]; ok {
+			if resources.Requests == nil {
+				resources.Requests = map[string]string{}
+			}
+			resources.Requests[string(name)] = quantity.String()
+		}
+		if quantity, ok := spec.Resources.Limits[name]; ok {
+			if resources.Limits == nil {
+				resources.Limits = map[string]string{}
+			}
+			resources.Limits[string(name)] = quantity.String()
+		}
+	}
+	return resources
+}
+
+// oomKilledState returns the terminated state of the current or previous
+// container instance if it was killed for exceeding its memory limit.
+func oomKilledState(container v1.ContainerStatus) *v1.ContainerStateTerminated {
+	if container.State.Terminated != nil && container.State.Terminated.Reason == "OOMKilled" {
+		return container.State.Terminated
+	}
+	if container.LastTerminationState.Terminated != nil && container.LastTerminationState.Terminated.Reason == "OOMKilled" {
+		return container.LastTerminationState.Terminated
+	}
+	return nil
+}
+
+func oomKilledNote(pod v1.Pod, containerName string, terminated *v1.ContainerStateTerminated) string {
+	request, limit := "not set", "not set"
+	if spec := specContainer(pod, containerName); spec != nil {
+		if quantity, ok := spec.Resources.Requests[v1.ResourceMemory]; ok {
+			request = quantity.String()
+		}
+		if quantity, ok := spec.Resources.Limits[v1.ResourceMemory]; ok {
+			limit = quantity.String()
+		}
+	}
+	return fmt.Sprintf("Container was killed for exceeding its memory limit (exit code %v), memory request: %v, memory limit: %v, raise \"resources.limits.memory\" in the deployment yaml or reduce the application's memory usage", terminated.ExitCode, request, limit)
+}
+
+// probeDiagnosis reports the readiness, liveness and startup probes of a
+// container that are failing according to its Unhealthy events. A running
+// container that is not ready without such an event is still waiting for
+// its readiness probe to succeed.
+func probeDiagnosis(pod v1.Pod, container v1.ContainerStatus, events []Event) []Diagnosis {
+	spec := specContainer(pod, container.Name)
+	if spec == nil {
+		return nil
+	}
+	probes := []struct {
+		kind  string
+		probe *v1.Probe
+	}{
+		{"Startup", spec.StartupProbe},
+		{"Liveness", spec.LivenessProbe},
+		{"Readiness", spec.ReadinessProbe},
+	}
+
+	var diagnosis []Diagnosis
+	for _, probe := range probes {
+		if probe.probe == nil {
+			continue
+		}
+		prefix := probe.kind + " probe failed"
+		var failure *Event
+		for i := len(events) - 1; i >= 0; i-- {
+			event := events[i]
+			if event.Reason == "Unhealthy" && strings.HasPrefix(event.Message, prefix) && (event.Container == "" || event.Container == container.Name) {
+				failure = &events[i]
+				break
+			}
+		}
+		reason := probe.kind + "ProbeFailed"
+		switch {
+		case failure != nil:
+			message := fmt.Sprintf("%v probe %v is failing (x%v): %v", probe.kind, describeProbe(probe.probe), failure.Count, strings.TrimSpace(strings.TrimPrefix(failure.Message, prefix+":")))
+			if probe.kind == "Liveness" {
+				message += ", the kubelet restarts the container every time it fails"
+			}
+			diagnosis = append(diagnosis, Diagnosis{Reason: reason, Message: message})
+		case probe.kind == "Readiness" && container.State.Running != nil && !container.Ready:
+			diagnosis = append(diagnosis, Diagnosis{Reason: reason, Message: fmt.Sprintf("Container is running but its readiness probe %v has not succeeded yet", describeProbe(probe.probe))})
+		}
+	}
+	return diagnosis
+}
+
+func describeProbe(probe *v1.Probe) string {
+	switch {
+	case probe.HTTPGet != nil:
+		return fmt.Sprintf("httpGet %v on port %v", probe.HTTPGet.Path, probe.HTTPGet.Port.String())
+	case probe.TCPSocket != nil:
+		return fmt.Sprintf("tcpSocket on port %v", probe.TCPSocket.Port.String())
+	case probe.GRPC != nil:
+		return fmt.Sprintf("grpc on port %v", probe.GRPC.Port)
+	case probe.Exec != nil:
+		return fmt.Sprintf("exec %q", strings.Join(probe.Exec.Command, " "))
+	}
+	return "(no handler)"
+}
+
+// flappingContainers returns the running and ready containers of the pods
+// that restarted more than threshold times, none if threshold is 0.
+func flappingContainers(pods *v1.PodList, threshold int32) []FlappingContainer {
+	if threshold <= 0 {
+		return nil
+	}
+	var flapping []FlappingContainer
+	for _, pod := range pods.Items {
+		for _, container := range pod.Status.ContainerStatuses {
+			if container.State.Running == nil || !container.Ready || container.RestartCount <= threshold {
+				continue
+			}
+			result := FlappingContainer{Pod: pod.Name, Container: container.Name, RestartCount: container.RestartCount}
+			if last := container.LastTerminationState.Terminated; last != nil {
+				result.LastReason, result.LastExitCode = last.Reason, last.ExitCode
+			}
+			flapping = append(flapping, result)
+		}
+	}
+	return flapping
+}
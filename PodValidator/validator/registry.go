@@ -0,0 +1,73 @@
+package validator
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// dockerHub is the registry of images without a registry host.
+const dockerHub = "docker.io"
+
+// registryHosts returns the registries a pull secret has credentials for,
+// from its .dockerconfigjson or legacy .dockercfg key. ok is false if the
+// secret holds neither.
+func registryHosts(secret *v1.Secret) (hosts []string, ok bool) {
+	var auths map[string]json.RawMessage
+	if data, found := secret.Data[v1.DockerConfigJsonKey]; found {
+		var config struct {
+			Auths map[string]json.RawMessage `json:"auths"`
+		}
+		if json.Unmarshal(data, &config) != nil {
+			return nil, false
+		}
+		auths = config.Auths
+	} else if data, found := secret.Data[v1.DockerConfigKey]; found {
+		if json.Unmarshal(data, &auths) != nil {
+			return nil, false
+		}
+	} else {
+		return nil, false
+	}
+	for server := range auths {
+		hosts = append(hosts, registryHost(server))
+	}
+	sort.Strings(hosts)
+	return hosts, true
+}
+
+// registryHost normalizes a docker config server such as
+// "https://index.docker.io/v1/" to its host.
+func registryHost(server string) string {
+	host := server
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	host, _, _ = strings.Cut(host, "/")
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return dockerHub
+	}
+	return host
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// imageRegistry returns the registry host of an image reference, the first
+// path component if it looks like a host and Docker Hub otherwise.
+func imageRegistry(image string) string {
+	host, _, found := strings.Cut(image, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		return dockerHub
+	}
+	return registryHost(host)
+}
@@ -0,0 +1,24 @@
+package validator
+
+import "testing"
+
+func TestImageRegistry(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"nginx", "docker.io"},
+		{"library/nginx:1.25", "docker.io"},
+		{"docker.io/library/nginx", "docker.io"},
+		{"index.docker.io/library/nginx", "docker.io"},
+		{"registry.example.com/team/web:1.0", "registry.example.com"},
+		{"localhost:5000/web", "localhost:5000"},
+		{"localhost/web", "localhost"},
+		{"123456789.dkr.ecr.eu-west-1.amazonaws.com/web@sha256:abc", "123456789.dkr.ecr.eu-west-1.amazonaws.com"},
+	}
+	for _, test := range tests {
+		if got := imageRegistry(test.image); got != test.want {
+			t.Errorf("imageRegistry(%q) = %q, want %q", test.image, got, test.want)
+		}
+	}
+}
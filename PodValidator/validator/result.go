@@ -0,0 +1,209 @@
+package validator
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// Result is the outcome of validating a workload.
+type Result struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Ready     bool   `json:"ready"`
+	// Paused is set for a paused Deployment that is not ready, which does
+	// not roll out until it is resumed.
+	Paused bool     `json:"paused,omitempty"`
+	Status []string `json:"status,omitempty"`
+	// Summary counts the ready and failing pods, whether or not they were
+	// diagnosed.
+	Summary Summary `json:"summary"`
+	// Images lists the images the containers of the pods run, more than one
+	// for a container when its replicas run different images.
+	Images []ImageVersion `json:"images,omitempty"`
+	// Flapping lists the running and ready containers that restarted more
+	// than Options.RestartThreshold times.
+	Flapping []FlappingContainer `json:"flapping,omitempty"`
+	Pods     []PodResult         `json:"pods,omitempty"`
+	// Service is the outcome of Options.ServiceProbe.
+	Service *ServiceResult `json:"service,omitempty"`
+	// ErrorGroups lists the container failures shared by several pods.
+	ErrorGroups []ErrorGroup `json:"errorGroups,omitempty"`
+	// Nodes lists the unhealthy nodes the failing pods are scheduled on.
+	Nodes []NodeProblem `json:"nodes,omitempty"`
+}
+
+// Summary counts the pods of a workload. Failing pods are neither ready nor
+// completed, Reasons counts why, most frequent first.
+type Summary struct {
+	Total     int           `json:"total"`
+	Ready     int           `json:"ready"`
+	Completed int           `json:"completed,omitempty"`
+	Failing   int           `json:"failing"`
+	Reasons   []ReasonCount `json:"reasons,omitempty"`
+}
+
+// ReasonCount is the number of failing pods with a reason.
+type ReasonCount struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+// ImageVersion is an image a container runs and the pods it runs in. ImageID
+// is the digest the node resolved the image to.
+type ImageVersion struct {
+	Container string   `json:"container"`
+	Image     string   `json:"image"`
+	ImageID   string   `json:"imageID"`
+	Pods      []string `json:"pods"`
+}
+
+// FlappingContainer is a container that is running and ready but restarted
+// often, which often precedes a crash loop. LastReason and LastExitCode are
+// of its last termination.
+type FlappingContainer struct {
+	Pod          string `json:"pod"`
+	Container    string `json:"container"`
+	RestartCount int32  `json:"restartCount"`
+	LastReason   string `json:"lastReason,omitempty"`
+	LastExitCode int32  `json:"lastExitCode"`
+}
+
+// NodeProblem is a node with problems, such as MemoryPressure or NotReady,
+// and the failing pods scheduled on it.
+type NodeProblem struct {
+	Name       string   `json:"name"`
+	Conditions []string `json:"conditions"`
+	Pods       []string `json:"pods"`
+}
+
+// ErrorGroup is a container failure that is identical, by reason and log
+// lines, in several pods.
+type ErrorGroup struct {
+	Container string   `json:"container"`
+	Reason    string   `json:"reason"`
+	Pods      []string `json:"pods"`
+}
+
+// ErrorGroup returns the group the container of the pod belongs to, or nil if
+// no other pod shares its failure.
+func (r *Result) ErrorGroup(pod, container string) *ErrorGroup {
+	for i, group := range r.ErrorGroups {
+		if group.Container != container {
+			continue
+		}
+		for _, name := range group.Pods {
+			if name == pod {
+				return &r.ErrorGroups[i]
+			}
+		}
+	}
+	return nil
+}
+
+// PodResult holds the diagnosis of a single pod. Diagnosis covers problems of
+// the pod as a whole, such as scheduling; Errors lists problems encountered
+// while diagnosing it, such as events that could not be listed. Completed
+// pods, in the Succeeded phase, finished their work and are not diagnosed.
+type PodResult struct {
+	Name       string            `json:"name"`
+	Phase      v1.PodPhase       `json:"phase"`
+	Node       string            `json:"node,omitempty"`
+	Ready      bool              `json:"ready"`
+	Completed  bool              `json:"completed,omitempty"`
+	Diagnosis  []Diagnosis       `json:"diagnosis,omitempty"`
+	Containers []ContainerResult `json:"containers"`
+	Events     []Event           `json:"events,omitempty"`
+	Errors     []string          `json:"errors,omitempty"`
+}
+
+// Event is a Warning event recorded for a pod. Container is set for events
+// about a single container, such as probe failures.
+type Event struct {
+	Reason    string `json:"reason"`
+	Message   string `json:"message"`
+	Count     int32  `json:"count"`
+	Container string `json:"container,omitempty"`
+}
+
+// ContainerResult holds the state and diagnosis of a single container. Init
+// is set for init containers, which are only reported while they have not
+// completed.
+type ContainerResult struct {
+	Name         string            `json:"name"`
+	Init         bool              `json:"init,omitempty"`
+	Sidecar      bool              `json:"sidecar,omitempty"`
+	Ready        bool              `json:"ready"`
+	RestartCount int32             `json:"restartCount"`
+	Image        string            `json:"image,omitempty"`
+	ImageID      string            `json:"imageID,omitempty"`
+	State        v1.ContainerState `json:"state"`
+	Reason       string            `json:"reason,omitempty"`
+	Diagnosis    []Diagnosis       `json:"diagnosis,omitempty"`
+	Logs         *Logs             `json:"logs,omitempty"`
+	Resources    *Resources        `json:"resources,omitempty"`
+	// Termination is the current or, if Last is set, previous exit of the
+	// container.
+	Termination *Termination `json:"termination,omitempty"`
+}
+
+// Termination is the exit code and signal of a terminated container with
+// their interpretation, e.g. 137 as SIGKILL.
+type Termination struct {
+	Last     bool   `json:"last,omitempty"`
+	ExitCode int32  `json:"exitCode"`
+	Signal   int32  `json:"signal,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	Meaning  string `json:"meaning"`
+}
+
+// Resources are the CPU and memory requests and limits of a container spec,
+// keyed by resource name.
+type Resources struct {
+	Requests map[string]string `json:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
+}
+
+// Diagnosis explains why a container is not ready. Category is the kind of
+// failure it is, one of the Category constants, and is the field to match on
+// in scripts and alerts. Reason is specific, such as FailedMount, and is the
+// Kubernetes reason or one of the diagnosis, so new ones can appear with any
+// release. Hints are the steps to fix it, if known.
+type Diagnosis struct {
+	Reason   string   `json:"reason"`
+	Category Category `json:"category"`
+	Message  string   `json:"message"`
+	Hints    []string `json:"hints,omitempty"`
+}
+
+// Logs are the container log lines matching the configured filter.
+type Logs struct {
+	Previous      bool     `json:"previous"`
+	Lines         []string `json:"lines,omitempty"`
+	Error         string   `json:"error,omitempty"`
+	PreviousError string   `json:"previousError,omitempty"`
+	// ReadError is set when the log stream broke off after Lines were read.
+	ReadError string `json:"readError,omitempty"`
+	// TruncatedAt is the LogOptions.MaxBytes the log was cut off at.
+	TruncatedAt int64 `json:"truncatedAt,omitempty"`
+	// Followed is set when the log was followed for LogOptions.Follow and
+	// Lines includes the lines that arrived meanwhile.
+	Followed bool `json:"followed,omitempty"`
+}
+
+// Reasons returns the diagnosed reasons of the pod and its containers in the
+// form "container: Reason: message".
+func (p PodResult) Reasons() []string {
+	var reasons []string
+	for _, diagnosis := range p.Diagnosis {
+		reasons = append(reasons, diagnosis.Reason+": "+diagnosis.Message)
+	}
+	for _, container := range p.Containers {
+		for _, diagnosis := range container.Diagnosis {
+			reasons = append(reasons, container.Name+": "+diagnosis.Reason+": "+diagnosis.Message)
+		}
+		if len(container.Diagnosis) == 0 && container.Reason != "" {
+			reasons = append(reasons, container.Name+": "+container.Reason)
+		}
+	}
+	return reasons
+}
@@ -0,0 +1,59 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// Backoff between retries of API calls that failed with a transient error.
+var (
+	retryInitialDelay = 500 * time.Millisecond
+	retryMaxDelay     = 10 * time.Second
+)
+
+// retry calls fn until it succeeds or fails with an error that is not
+// transient, backing off exponentially in between. It gives up once
+// Options.Timeout has passed since the first attempt or ctx is done and
+// returns the last error.
+func (v *Validator) retry(ctx context.Context, what string, fn func() error) error {
+	deadline := time.Now().Add(v.options.Timeout)
+	delay := retryInitialDelay
+	for {
+		err := fn()
+		if err == nil || !retryable(err) || time.Now().Add(delay).After(deadline) {
+			return err
+		}
+		v.logger().Warn(fmt.Sprintf("Error %v, retrying in %v", what, delay), "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
+
+// retryable reports whether err is a brief API server or network hiccup,
+// such as a timeout, throttling or a 5xx response, rather than an answer
+// like not found or forbidden that will not change on retry.
+func retryable(err error) bool {
+	switch {
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), apierrors.IsTooManyRequests(err),
+		apierrors.IsInternalError(err), apierrors.IsServiceUnavailable(err), apierrors.IsUnexpectedServerError(err):
+		return true
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return utilnet.IsConnectionReset(err) || utilnet.IsConnectionRefused(err) || errors.Is(err, io.ErrUnexpectedEOF)
+}
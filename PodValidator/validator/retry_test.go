@@ -0,0 +1,63 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRetryable(t *testing.T) {
+	resource := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server timeout", apierrors.NewServerTimeout(resource, "get", 1), true},
+		{"too many requests", apierrors.NewTooManyRequests("throttled", 1), true},
+		{"internal error", apierrors.NewInternalError(errors.New("etcd leader changed")), true},
+		{"service unavailable", apierrors.NewServiceUnavailable("unavailable"), true},
+		{"not found", apierrors.NewNotFound(resource, "web"), false},
+		{"forbidden", apierrors.NewForbidden(resource, "web", errors.New("rbac")), false},
+		{"canceled", context.Canceled, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := retryable(test.err); got != test.want {
+				t.Errorf("retryable(%v) = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateRetriesTransientErrors(t *testing.T) {
+	retryInitialDelay = time.Millisecond
+	defer func() { retryInitialDelay = 500 * time.Millisecond }()
+
+	client := fake.NewSimpleClientset(testDeployment(true))
+	failures := 2
+	client.PrependReactor("get", "deployments", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if failures == 0 {
+			return false, nil, nil
+		}
+		failures--
+		return true, nil, apierrors.NewServiceUnavailable("apiserver is shutting down")
+	})
+
+	opts := testOptions()
+	opts.Timeout = time.Second
+	result, err := New(client, opts).Validate(context.Background())
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !result.Ready || failures != 0 {
+		t.Errorf("expected the deployment to be found after retrying, got ready %v with %d failures left", result.Ready, failures)
+	}
+}
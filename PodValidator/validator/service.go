@@ -0,0 +1,190 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ServiceProbe configures an HTTP GET of the Service in front of the workload
+// once it is ready, since readiness probes can pass while the application
+// still fails real requests.
+type ServiceProbe struct {
+	// Path is requested from the Service, "/" if empty.
+	Path string
+	// Port is the name or number of the Service port, its first port if empty.
+	Port string
+}
+
+// ServiceResult is the outcome of the ServiceProbe.
+type ServiceResult struct {
+	Name       string `json:"name,omitempty"`
+	Port       string `json:"port,omitempty"`
+	Path       string `json:"path"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Healthy reports whether the Service answered with a 2xx or 3xx status.
+func (s ServiceResult) Healthy() bool {
+	return s.Error == "" && s.StatusCode >= 200 && s.StatusCode < 400
+}
+
+// probeService finds the Service selecting the pods and requests the probe
+// path from it through the API server's service proxy, so that it works from
+// outside the cluster.
+func (v *Validator) probeService(ctx context.Context, pods *v1.PodList) ServiceResult {
+	probe := v.options.ServiceProbe
+	result := ServiceResult{Path: probe.Path, Port: probe.Port}
+	if result.Path == "" {
+		result.Path = "/"
+	}
+	if len(pods.Items) == 0 {
+		result.Error = "no pods to find the Service of"
+		return result
+	}
+
+	var services *v1.ServiceList
+	err := v.retry(ctx, "listing services", func() (err error) {
+		services, err = v.client.CoreV1().Services(v.options.Namespace).List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		result.Error = fmt.Sprintf("error listing services: %v", err)
+		return result
+	}
+	podLabels := labels.Set(pods.Items[0].Labels)
+	var service *v1.Service
+	for i, candidate := range services.Items {
+		if len(candidate.Spec.Selector) > 0 && labels.SelectorFromSet(candidate.Spec.Selector).Matches(podLabels) {
+			service = &services.Items[i]
+			break
+		}
+	}
+	if service == nil {
+		result.Error = fmt.Sprintf("no Service in namespace %v selects pod %v", v.options.Namespace, pods.Items[0].Name)
+		return result
+	}
+	result.Name = service.Name
+	if result.Port == "" && len(service.Spec.Ports) > 0 {
+		result.Port = service.Spec.Ports[0].Name
+		if result.Port == "" {
+			result.Port = strconv.Itoa(int(service.Spec.Ports[0].Port))
+		}
+	}
+
+	_, err = v.client.CoreV1().Services(v.options.Namespace).ProxyGet("http", service.Name, result.Port, result.Path, nil).DoRaw(ctx)
+	var status apierrors.APIStatus
+	switch {
+	case err == nil:
+		result.StatusCode = 200
+	case errors.As(err, &status) && status.Status().Code != 0:
+		result.StatusCode = int(status.Status().Code)
+	default:
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// serviceStatus validates the pods behind a Service's selector. The Service is
+// ready once it has endpoints and all of them are ready, as counted in its
+// EndpointSlices or, if those cannot be listed, its Endpoints: that is what
+// decides whether it routes to every pod.
+func (v *Validator) serviceStatus(ctx context.Context, service *v1.Service) (workload, error) {
+	var w workload
+	if len(service.Spec.Selector) == 0 {
+		w.report("Service %v has no selector, its endpoints are not backed by pods to validate", service.Name)
+		w.failed = true
+		return w, nil
+	}
+	w.selector = labels.SelectorFromSet(service.Spec.Selector).String()
+
+	ready, notReady, err := v.endpointSliceCounts(ctx, service)
+	if err != nil {
+		ready, notReady, err = v.endpointsCounts(ctx, service)
+	}
+	if err != nil {
+		return workload{}, fmt.Errorf("error getting the endpoints of service %v: %w", service.Name, err)
+	}
+	w.report("Service %v: %v ready, %v not ready endpoints", service.Name, ready, notReady)
+	switch {
+	case ready == 0 && notReady == 0:
+		w.report("Service %v has no endpoints, no pod matching selector %v is running", service.Name, w.selector)
+	case ready == 0:
+		w.report("Service %v is not routable, none of its endpoints is ready", service.Name)
+	}
+	w.ready = ready > 0 && notReady == 0
+	return w, nil
+}
+
+// endpointSliceCounts counts the ready and not ready endpoints in the
+// EndpointSlices of the service. An endpoint in several slices, e.g. one for
+// each address family, is counted once.
+func (v *Validator) endpointSliceCounts(ctx context.Context, service *v1.Service) (int, int, error) {
+	var slices *discoveryv1.EndpointSliceList
+	err := v.retry(ctx, "listing endpoint slices", func() (err error) {
+		slices, err = v.client.DiscoveryV1().EndpointSlices(service.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: service.Name}).String(),
+		})
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	ready, notReady := map[string]bool{}, map[string]bool{}
+	for _, slice := range slices.Items {
+		for _, endpoint := range slice.Endpoints {
+			key := endpointKey(endpoint.TargetRef, endpoint.Addresses)
+			// A nil Ready condition is to be understood as ready.
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				ready[key] = true
+			} else {
+				notReady[key] = true
+			}
+		}
+	}
+	return len(ready), len(notReady), nil
+}
+
+// endpointsCounts counts the ready and not ready addresses of the Endpoints of
+// the service, for clusters where EndpointSlices cannot be listed.
+func (v *Validator) endpointsCounts(ctx context.Context, service *v1.Service) (int, int, error) {
+	var endpoints *v1.Endpoints
+	err := v.retry(ctx, "getting endpoints", func() (err error) {
+		endpoints, err = v.client.CoreV1().Endpoints(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	ready, notReady := map[string]bool{}, map[string]bool{}
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			ready[endpointKey(address.TargetRef, []string{address.IP})] = true
+		}
+		for _, address := range subset.NotReadyAddresses {
+			notReady[endpointKey(address.TargetRef, []string{address.IP})] = true
+		}
+	}
+	return len(ready), len(notReady), nil
+}
+
+// endpointKey identifies an endpoint by the pod it targets, or by its
+// addresses if it does not target a pod.
+func endpointKey(target *v1.ObjectReference, addresses []string) string {
+	if target != nil && target.UID != "" {
+		return string(target.UID)
+	}
+	return strings.Join(addresses, ",")
+}
@@ -0,0 +1,113 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SnapshotVersion is the version of the Snapshot format, increased whenever
+// a change makes older snapshots unreadable.
+const SnapshotVersion = 1
+
+// Snapshot is the state of a workload after a successful validation, to
+// compare a later validation to.
+type Snapshot struct {
+	Version   int             `json:"version"`
+	Namespace string          `json:"namespace"`
+	Kind      string          `json:"kind"`
+	Name      string          `json:"name"`
+	Taken     time.Time       `json:"taken"`
+	Replicas  int             `json:"replicas"`
+	Images    []SnapshotImage `json:"images"`
+}
+
+// SnapshotImage is an image a container ran at the time of a Snapshot.
+type SnapshotImage struct {
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	ImageID   string `json:"imageID"`
+}
+
+func (i SnapshotImage) String() string {
+	return fmt.Sprintf("%v (%v)", i.Image, ImageDigest(i.ImageID))
+}
+
+// NewSnapshot records the replicas and images of the result.
+func NewSnapshot(result *Result, taken time.Time) Snapshot {
+	snapshot := Snapshot{
+		Version:   SnapshotVersion,
+		Namespace: result.Namespace,
+		Kind:      result.Kind,
+		Name:      result.Name,
+		Taken:     taken,
+		Replicas:  result.Summary.Total - result.Summary.Completed,
+	}
+	for _, version := range result.Images {
+		snapshot.Images = append(snapshot.Images, SnapshotImage{Container: version.Container, Image: version.Image, ImageID: version.ImageID})
+	}
+	return snapshot
+}
+
+// Changes lists what differs in the result from the snapshot: changed
+// images, a changed number of replicas and, as the snapshot was taken when
+// every container was ready, the containers that are failing now.
+func (s Snapshot) Changes(result *Result) []string {
+	var changes []string
+	if replicas := result.Summary.Total - result.Summary.Completed; replicas != s.Replicas {
+		changes = append(changes, fmt.Sprintf("Replicas changed from %v to %v", s.Replicas, replicas))
+	}
+
+	before, after := map[string][]string{}, map[string][]string{}
+	var containers []string
+	for _, image := range s.Images {
+		if _, ok := before[image.Container]; !ok {
+			containers = append(containers, image.Container)
+		}
+		before[image.Container] = append(before[image.Container], image.String())
+	}
+	for _, version := range result.Images {
+		if _, ok := before[version.Container]; !ok {
+			if _, ok := after[version.Container]; !ok {
+				containers = append(containers, version.Container)
+			}
+		}
+		after[version.Container] = append(after[version.Container], SnapshotImage{Image: version.Image, ImageID: version.ImageID}.String())
+	}
+	for _, container := range containers {
+		sort.Strings(before[container])
+		sort.Strings(after[container])
+		was, now := strings.Join(before[container], ", "), strings.Join(after[container], ", ")
+		switch {
+		case was == now:
+		case was == "":
+			changes = append(changes, fmt.Sprintf("Container %v is new, running %v", container, now))
+		case now == "":
+			changes = append(changes, fmt.Sprintf("Container %v, running %v before, is gone or not running", container, was))
+		default:
+			changes = append(changes, fmt.Sprintf("Container %v changed its image from %v to %v", container, was, now))
+		}
+	}
+
+	failing := map[string][]string{}
+	for _, pod := range result.Pods {
+		if pod.Completed {
+			continue
+		}
+		for _, container := range pod.Containers {
+			if !container.Ready {
+				failing[container.Name] = append(failing[container.Name], pod.Name)
+			}
+		}
+	}
+	var names []string
+	for name := range failing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		changes = append(changes, fmt.Sprintf("Container %v is failing now in %v", name, strings.Join(failing[name], ", ")))
+	}
+	return changes
+}
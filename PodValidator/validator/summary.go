@@ -0,0 +1,88 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// String formats the summary like "3/5 pods ready, 2 failing (1
+// ImagePullBackOff, 1 CrashLoopBackOff)". Completed pods are not counted as
+// pods that should be ready.
+func (s Summary) String() string {
+	summary := fmt.Sprintf("%v/%v pods ready", s.Ready, s.Total-s.Completed)
+	if s.Completed > 0 {
+		summary += fmt.Sprintf(", %v completed", s.Completed)
+	}
+	if s.Failing > 0 {
+		var reasons []string
+		for _, reason := range s.Reasons {
+			reasons = append(reasons, fmt.Sprintf("%v %v", reason.Count, reason.Reason))
+		}
+		summary += fmt.Sprintf(", %v failing (%v)", s.Failing, strings.Join(reasons, ", "))
+	}
+	return summary
+}
+
+// summarize counts the ready, completed and failing pods and the reasons the
+// failing ones are failing for.
+func summarize(pods *v1.PodList) Summary {
+	summary := Summary{Total: len(pods.Items)}
+	counts := map[string]int{}
+	for _, pod := range pods.Items {
+		switch {
+		case pod.Status.Phase == v1.PodSucceeded:
+			summary.Completed++
+		case podReady(pod):
+			summary.Ready++
+		default:
+			summary.Failing++
+			counts[podReason(pod)]++
+		}
+	}
+	for reason, count := range counts {
+		summary.Reasons = append(summary.Reasons, ReasonCount{Reason: reason, Count: count})
+	}
+	sort.Slice(summary.Reasons, func(i, j int) bool {
+		if summary.Reasons[i].Count != summary.Reasons[j].Count {
+			return summary.Reasons[i].Count > summary.Reasons[j].Count
+		}
+		return summary.Reasons[i].Reason < summary.Reasons[j].Reason
+	})
+	return summary
+}
+
+// podReason is the most specific reason a pod is not ready found in its
+// status: the pod reason, such as Evicted, a scheduling failure, the reason of
+// the first failing container, init containers first, or else its phase.
+func podReason(pod v1.Pod) string {
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse && condition.Reason != "" {
+			return condition.Reason
+		}
+	}
+	for _, containers := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, container := range containers {
+			if waiting := container.State.Waiting; waiting != nil && waiting.Reason != "" {
+				return waiting.Reason
+			}
+			if terminated := container.State.Terminated; terminated != nil && terminated.ExitCode != 0 && terminated.Reason != "" {
+				return terminated.Reason
+			}
+		}
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady && condition.Reason != "" {
+			return condition.Reason
+		}
+	}
+	if pod.Status.Phase != "" {
+		return string(pod.Status.Phase)
+	}
+	return "Unknown"
+}
@@ -0,0 +1,85 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/pager"
+)
+
+// Target is a workload to validate or, without a Name, the pods matching the
+// sweep selector in Namespace.
+type Target struct {
+	Namespace string
+	Name      string
+}
+
+// FindTargets lists the targets of a sweep across all namespaces: the
+// workloads of kind matching selector or, if kind is empty, every namespace
+// with pods matching selector. Targets are sorted by namespace and name. The
+// objects are listed in pages, there may be many across all namespaces.
+func FindTargets(ctx context.Context, client kubernetes.Interface, kind, selector string) ([]Target, error) {
+	if kind == "deploymentconfig" {
+		return nil, fmt.Errorf("%v is not supported in all namespaces", kind)
+	}
+	pages := pager.New(func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+		switch kind {
+		case "":
+			return client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, options)
+		case "statefulset":
+			return client.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, options)
+		case "daemonset":
+			return client.AppsV1().DaemonSets(metav1.NamespaceAll).List(ctx, options)
+		case "replicaset":
+			return client.AppsV1().ReplicaSets(metav1.NamespaceAll).List(ctx, options)
+		case "job":
+			return client.BatchV1().Jobs(metav1.NamespaceAll).List(ctx, options)
+		case "cronjob":
+			return client.BatchV1().CronJobs(metav1.NamespaceAll).List(ctx, options)
+		case "service":
+			return client.CoreV1().Services(metav1.NamespaceAll).List(ctx, options)
+		default:
+			return client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, options)
+		}
+	})
+
+	var targets []Target
+	seen := map[Target]bool{}
+	err := pages.EachListItem(ctx, metav1.ListOptions{LabelSelector: selector}, func(item runtime.Object) error {
+		accessor, err := meta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		target := Target{Namespace: accessor.GetNamespace()}
+		if kind != "" {
+			target.Name = accessor.GetName()
+		}
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing %v in all namespaces: %w", kindOrPods(kind), err)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Namespace != targets[j].Namespace {
+			return targets[i].Namespace < targets[j].Namespace
+		}
+		return targets[i].Name < targets[j].Name
+	})
+	return targets, nil
+}
+
+func kindOrPods(kind string) string {
+	if kind == "" {
+		return "pods"
+	}
+	return kind
+}
@@ -0,0 +1,40 @@
+package validator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	Appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFindTargets(t *testing.T) {
+	labels := map[string]string{"app": "web"}
+	client := fake.NewSimpleClientset(
+		&Appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod", Labels: labels}},
+		&Appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev", Labels: labels}},
+		&Appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "dev"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "prod", Labels: labels}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "prod", Labels: labels}},
+	)
+
+	tests := []struct {
+		kind string
+		want []Target
+	}{
+		{kind: "deployment", want: []Target{{Namespace: "dev", Name: "web"}, {Namespace: "prod", Name: "web"}}},
+		{kind: "", want: []Target{{Namespace: "prod"}}},
+	}
+	for _, test := range tests {
+		targets, err := FindTargets(context.Background(), client, test.kind, "app=web")
+		if err != nil {
+			t.Fatalf("FindTargets(%q) error = %v", test.kind, err)
+		}
+		if !reflect.DeepEqual(targets, test.want) {
+			t.Errorf("FindTargets(%q) = %+v, want %+v", test.kind, targets, test.want)
+		}
+	}
+}
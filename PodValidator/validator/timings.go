@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"sync"
+	"time"
+)
+
+// Timing is the time spent in a phase of the validation, such as listing
+// pods, summed over the Count times it ran. Phases that run concurrently,
+// such as fetching the logs of several containers, add up to more than the
+// time they took.
+type Timing struct {
+	Phase    string        `json:"phase"`
+	Count    int           `json:"count"`
+	Duration time.Duration `json:"duration"`
+}
+
+// timings accumulates the Timing of each phase, in the order the phases first
+// ran.
+type timings struct {
+	mu     sync.Mutex
+	phases []Timing
+	index  map[string]int
+}
+
+// track adds the time since start to phase, it is meant to be deferred as
+// defer v.track("list pods", time.Now()).
+func (v *Validator) track(phase string, start time.Time) {
+	elapsed := time.Since(start)
+	v.timings.mu.Lock()
+	defer v.timings.mu.Unlock()
+	if v.timings.index == nil {
+		v.timings.index = map[string]int{}
+	}
+	i, ok := v.timings.index[phase]
+	if !ok {
+		i = len(v.timings.phases)
+		v.timings.index[phase] = i
+		v.timings.phases = append(v.timings.phases, Timing{Phase: phase})
+	}
+	v.timings.phases[i].Count++
+	v.timings.phases[i].Duration += elapsed
+}
+
+// Timings returns the time spent in each phase of the validations and access
+// checks run so far.
+func (v *Validator) Timings() []Timing {
+	v.timings.mu.Lock()
+	defer v.timings.mu.Unlock()
+	return append([]Timing(nil), v.timings.phases...)
+}
@@ -0,0 +1,311 @@
+// Package validator checks whether a Kubernetes workload came up and, when it
+// did not, diagnoses why its pods are failing.
+package validator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/pager"
+)
+
+// Options configure a validation run.
+type Options struct {
+	Namespace string
+	// Kind is one of SupportedKinds, it is ignored when Selector or
+	// Resource is set.
+	Kind string
+	Name string
+	// Selector validates the pods matching this label selector instead of a workload.
+	Selector string
+	Timeout  time.Duration
+	Interval time.Duration
+	// Watch waits for status changes with a watch instead of polling every
+	// Interval, falling back to polling if the watch is closed early.
+	Watch bool
+	// FollowEvents logs the Warning events of the pods while waiting.
+	FollowEvents bool
+	Logs         LogOptions
+	// ServiceProbe checks the Service in front of the workload once it is
+	// ready, nil skips the check.
+	ServiceProbe *ServiceProbe
+	// Resource validates a workload of any resource with the dynamic client
+	// instead of one of the supported kinds.
+	Resource *CustomResource
+	// Condition is the condition a Deployment is ready with. If it is not set
+	// the Deployment is ready once its rollout is complete, as reported by
+	// kubectl rollout status.
+	Condition Condition
+	// RestartThreshold reports running and ready containers that restarted
+	// more often as flapping, 0 does not report them.
+	RestartThreshold int32
+	// FailFast stops diagnosing at the first failing pod, in the order of
+	// the pod names, and only reports that one.
+	FailFast bool
+	// Containers restricts the diagnosis, and the logs fetched, to the
+	// containers of these names, to leave out e.g. sidecars. All containers
+	// are diagnosed if it is empty. The readiness of a pod still depends on
+	// all of its containers, failing excluded ones are named but not
+	// diagnosed.
+	Containers []string
+	// MaxConcurrentPods bounds the pods diagnosed at once, 10 if it is not
+	// set.
+	MaxConcurrentPods int
+	// Revision, if set, requires a Deployment to have rolled out this
+	// deployment.kubernetes.io/revision instead of any revision.
+	Revision int64
+}
+
+// Validator validates a single workload.
+type Validator struct {
+	client  kubernetes.Interface
+	options Options
+
+	// Logger receives progress messages while waiting for the workload,
+	// slog.Default() is used if it is nil.
+	Logger *slog.Logger
+	// Dynamic reads the kinds without a typed client, such as the OpenShift
+	// deploymentconfig. It is only needed for those kinds.
+	Dynamic dynamic.Interface
+
+	timings timings
+}
+
+// New returns a Validator for the workload described by options.
+func New(client kubernetes.Interface, options Options) *Validator {
+	return &Validator{client: client, options: options}
+}
+
+func (v *Validator) logger() *slog.Logger {
+	if v.Logger != nil {
+		return v.Logger
+	}
+	return slog.Default()
+}
+
+// Validate waits up to Options.Timeout for the workload to become ready and
+// diagnoses its pods if it does not. An error is only returned when the
+// Kubernetes API could not be queried or ctx is done.
+func (v *Validator) Validate(ctx context.Context) (*Result, error) {
+	opts := v.options
+	result := &Result{Namespace: opts.Namespace, Kind: v.kindName(), Name: opts.Name}
+	if opts.Selector != "" {
+		result.Kind, result.Name = "Pods", opts.Selector
+	}
+
+	target, pods, err := v.check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Status = target.status
+
+	stopEvents := func() {}
+	if opts.FollowEvents && !target.ready && !target.failed {
+		stopEvents = v.followEvents(ctx, target.selector)
+	}
+	defer stopEvents()
+
+	count := int(opts.Timeout/opts.Interval) + 1
+	if opts.Watch && opts.Selector == "" && !target.ready && !target.failed {
+		deadline := time.Now().Add(opts.Timeout)
+		var done bool
+		watched := time.Now()
+		target, done, err = v.watch(ctx, deadline, target)
+		v.track("wait for "+result.Kind, watched)
+		if err != nil {
+			return nil, err
+		}
+		result.Status = target.status
+		// The pods changed while watching the workload.
+		if pods, err = v.listPods(ctx, target.selector); err != nil {
+			return nil, err
+		}
+		pods = ownedPods(pods, target.owner)
+		// Only the final check is left once the watch saw the workload settle or time out.
+		count = 1
+		if !done {
+			v.logger().Warn(fmt.Sprintf("Watch on %v closed, falling back to polling", result.Kind), "interval", opts.Interval)
+			count = int(time.Until(deadline)/opts.Interval) + 1
+		}
+	}
+	for ; count > 0; count-- {
+		if target.ready {
+			result.Ready = true
+			result.Summary = summarize(pods)
+			result.Images = imageVersions(pods)
+			result.Flapping = flappingContainers(pods, opts.RestartThreshold)
+			if opts.ServiceProbe != nil {
+				service := v.probeService(ctx, pods)
+				result.Service = &service
+				if service.Error != "" {
+					result.Status = append(result.Status, fmt.Sprintf("Service probe failed: %v", service.Error))
+				} else {
+					result.Status = append(result.Status, fmt.Sprintf("Service %v GET port %v path %v returned %v", service.Name, service.Port, service.Path, service.StatusCode))
+				}
+				result.Ready = service.Healthy()
+			}
+			return result, nil
+		}
+		if count == 1 || target.failed {
+			break
+		}
+		for _, status := range target.status {
+			v.logger().Info(status)
+		}
+		v.logger().Warn(fmt.Sprintf("%v is not up yet, trying again in %v...", result.Kind, opts.Interval), "namespace", opts.Namespace, "name", result.Name)
+		waited := time.Now()
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("validation aborted: %w", ctx.Err())
+		case <-time.After(opts.Interval):
+		}
+		v.track("wait for "+result.Kind, waited)
+		if target, pods, err = v.check(ctx); err != nil {
+			return nil, err
+		}
+		result.Status = target.status
+	}
+
+	stopEvents()
+	result.Paused = target.paused
+	result.Summary = summarize(pods)
+	result.Images = imageVersions(pods)
+	result.Flapping = flappingContainers(pods, opts.RestartThreshold)
+	if target.stale {
+		// The pods may still be those of the previous spec.
+		result.Status = append(result.Status, fmt.Sprintf("The controller did not observe the latest spec of %v %v in time, its pods are not diagnosed as they may be of the previous spec", result.Kind, result.Name))
+		return result, nil
+	}
+	if len(pods.Items) == 0 {
+		result.Status = append(result.Status, noPodsMessage(target.selector, opts.Namespace, opts.Selector != ""))
+	}
+	if unknown := v.unselectedContainers(pods); len(pods.Items) > 0 && len(unknown) > 0 {
+		result.Status = append(result.Status, fmt.Sprintf("No pod has a container named %v, check the selected container names for typos", strings.Join(unknown, ", ")))
+	}
+	result.Pods = v.diagnosePods(ctx, pods)
+	if skipped := len(pods.Items) - len(result.Pods); skipped > 0 {
+		result.Status = append(result.Status, fmt.Sprintf("Stopped at the first failing pod %v, %v other pods are not reported", result.Pods[0].Name, skipped))
+	}
+	result.Nodes = v.diagnoseNodes(ctx, result.Pods)
+	result.ErrorGroups = groupErrors(result.Pods)
+	return result, nil
+}
+
+// noPodsMessage explains a workload without any pods to diagnose. A selector
+// given on the command line is probably mistyped, the selector of a workload
+// can also match nothing because its pods could not be created.
+func noPodsMessage(selector, namespace string, explicit bool) string {
+	if explicit {
+		return fmt.Sprintf("No pods match selector %v in namespace %v, check the selector for typos and the labels of the pods", selector, namespace)
+	}
+	return fmt.Sprintf("No pods match selector %v in namespace %v, check that the selector matches the labels of the pod template and the Warning events of the workload, e.g. for an exceeded quota", selector, namespace)
+}
+
+// check fetches the current state of the workload and its pods. Both are
+// fetched again on every poll so that a rollout progressing during the wait
+// is noticed and the pods it created are the ones diagnosed.
+func (v *Validator) check(ctx context.Context) (workload, *v1.PodList, error) {
+	opts := v.options
+	target := workload{selector: opts.Selector}
+	if opts.Selector == "" {
+		var err error
+		target, err = v.getWorkload(ctx)
+		if err != nil {
+			return workload{}, nil, fmt.Errorf("error getting %v: %w", v.resourceName(), err)
+		}
+	}
+
+	pods, err := v.listPods(ctx, target.selector)
+	if err != nil {
+		return workload{}, nil, err
+	}
+	pods = ownedPods(pods, target.owner)
+	if opts.Selector != "" {
+		target = podsStatus(pods, opts.Selector)
+	}
+	return target, pods, nil
+}
+
+// listPods lists the pods matching selector in pages of 500, a large
+// namespace would otherwise only be listed as far as the first page. Every
+// page is retried on its own. The pods are sorted by name so that the
+// results, which are diagnosed concurrently but stored by index, are
+// reported in the same order on every run.
+func (v *Validator) listPods(ctx context.Context, selector string) (*v1.PodList, error) {
+	defer v.track("list pods", time.Now())
+	pages := pager.New(func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+		var page *v1.PodList
+		err := v.retry(ctx, "getting pods", func() (err error) {
+			page, err = v.client.CoreV1().Pods(v.options.Namespace).List(ctx, options)
+			return err
+		})
+		return page, err
+	})
+	pods := &v1.PodList{}
+	err := pages.EachListItem(ctx, metav1.ListOptions{LabelSelector: selector}, func(object runtime.Object) error {
+		pods.Items = append(pods.Items, *object.(*v1.Pod))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting pods: %w", err)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+	return pods, nil
+}
+
+// watch waits until the workload is ready, failed, or deadline passes. done is
+// false if the watch could not be established or was closed before that, so
+// the caller can fall back to polling.
+func (v *Validator) watch(ctx context.Context, deadline time.Time, target workload) (workload, bool, error) {
+	result := target
+	watchCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	watcher, err := v.watchWorkload(watchCtx, metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", v.options.Name).String(),
+		ResourceVersion: target.resourceVersion,
+	})
+	if err != nil {
+		return result, false, nil
+	}
+	defer watcher.Stop()
+
+	v.logger().Info(fmt.Sprintf("%v is not up yet, watching for changes for up to %v...", v.kindName(), time.Until(deadline).Round(time.Second)), "namespace", v.options.Namespace, "name", v.options.Name)
+	for {
+		select {
+		case <-watchCtx.Done():
+			if ctx.Err() != nil {
+				return result, false, fmt.Errorf("validation aborted: %w", ctx.Err())
+			}
+			return result, true, nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok || event.Type == watch.Error {
+				return result, false, nil
+			}
+			if event.Type != watch.Added && event.Type != watch.Modified {
+				continue
+			}
+			updated, err := v.workloadStatus(ctx, event.Object)
+			if err != nil {
+				return result, false, fmt.Errorf("error getting %v: %w", v.resourceName(), err)
+			}
+			result = updated
+			if result.ready || result.failed {
+				return result, true, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,1713 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	Appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testNamespace = "apps"
+
+func testDeployment(available bool) *Appsv1.Deployment {
+	status, replicas := v1.ConditionFalse, int32(0)
+	if available {
+		status, replicas = v1.ConditionTrue, 1
+	}
+	return &Appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+		Spec: Appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		Status: Appsv1.DeploymentStatus{
+			Replicas:          replicas,
+			UpdatedReplicas:   replicas,
+			ReadyReplicas:     replicas,
+			AvailableReplicas: replicas,
+			Conditions:        []Appsv1.DeploymentCondition{{Type: Appsv1.DeploymentAvailable, Status: status}},
+		},
+	}
+}
+
+func testPod(name string, container v1.ContainerStatus) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: map[string]string{"app": "web"}},
+		Spec: v1.PodSpec{
+			Containers:       []v1.Container{{Name: container.Name, Image: "registry.example.com/web:1.0"}},
+			ImagePullSecrets: []v1.LocalObjectReference{{Name: "registry"}},
+		},
+		Status: v1.PodStatus{
+			Phase:             v1.PodPending,
+			ContainerStatuses: []v1.ContainerStatus{container},
+		},
+	}
+}
+
+func waitingContainer(reason string, restarts int32) v1.ContainerStatus {
+	return v1.ContainerStatus{
+		Name:         "app",
+		RestartCount: restarts,
+		State:        v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: reason}},
+	}
+}
+
+func testOptions() Options {
+	match, _ := CompilePatterns("error")
+	return Options{
+		Namespace: testNamespace,
+		Kind:      "deployment",
+		Name:      "web",
+		Interval:  time.Millisecond,
+		Logs:      LogOptions{Match: match, MaxLines: 10},
+	}
+}
+
+func validate(t *testing.T, opts Options, objects ...runtime.Object) *Result {
+	t.Helper()
+	result, err := New(fake.NewSimpleClientset(objects...), opts).Validate(context.Background())
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	return result
+}
+
+// withoutHints returns the diagnoses without their Hints, which are the steps
+// of Explain tested on their own.
+func withoutHints(diagnoses []Diagnosis) []Diagnosis {
+	stripped := make([]Diagnosis, len(diagnoses))
+	for i, diagnosis := range diagnoses {
+		diagnosis.Hints = nil
+		stripped[i] = diagnosis
+	}
+	return stripped
+}
+
+func onlyContainer(t *testing.T, result *Result) ContainerResult {
+	t.Helper()
+	if len(result.Pods) != 1 || len(result.Pods[0].Containers) != 1 {
+		t.Fatalf("expected one pod with one container, got %+v", result.Pods)
+	}
+	return result.Pods[0].Containers[0]
+}
+
+func TestValidateAvailableDeployment(t *testing.T) {
+	result := validate(t, testOptions(), testDeployment(true))
+	if !result.Ready {
+		t.Fatalf("expected deployment to be ready")
+	}
+	if result.Kind != "Deployment" || result.Name != "web" || result.Namespace != testNamespace {
+		t.Errorf("unexpected result identity %v/%v/%v", result.Namespace, result.Kind, result.Name)
+	}
+	if len(result.Pods) != 0 {
+		t.Errorf("expected no pod diagnosis for a ready deployment, got %d pods", len(result.Pods))
+	}
+}
+
+func TestValidateMissingDeployment(t *testing.T) {
+	_, err := New(fake.NewSimpleClientset(), testOptions()).Validate(context.Background())
+	if err == nil {
+		t.Fatalf("expected an error for a missing deployment")
+	}
+}
+
+func TestValidateImagePullBackOff(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		message string
+	}{
+		{
+			name:    "missing secret",
+			message: "Error getting secret registry: secrets \"registry\" not found in namspace apps, please add them",
+		},
+		{
+			name: "present secret",
+			objects: []runtime.Object{
+				&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: testNamespace}},
+			},
+			message: "Secret registry is present in namespace apps, this error could be due to expired or wrong values in the secret",
+		},
+		{
+			name: "secret for another registry",
+			objects: []runtime.Object{
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: testNamespace},
+					Type:       v1.SecretTypeDockerConfigJson,
+					Data:       map[string][]byte{v1.DockerConfigJsonKey: []byte(`{"auths":{"https://index.docker.io/v1/":{"auth":"dXNlcjpwYXNz"}}}`)},
+				},
+			},
+			message: "Secret registry only has credentials for docker.io, but image registry.example.com/web:1.0 is pulled from registry.example.com, add credentials for registry.example.com to the secret",
+		},
+		{
+			name: "secret for the image registry",
+			objects: []runtime.Object{
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: testNamespace},
+					Type:       v1.SecretTypeDockerConfigJson,
+					Data:       map[string][]byte{v1.DockerConfigJsonKey: []byte(`{"auths":{"registry.example.com":{"auth":"dXNlcjpwYXNz"}}}`)},
+				},
+			},
+			message: "Secret registry is present in namespace apps and has credentials for registry.example.com, this error could be due to expired or wrong values in the secret or a wrong image name registry.example.com/web:1.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := append(tt.objects, testDeployment(false), testPod("web-1", waitingContainer("ImagePullBackOff", 0)))
+			result := validate(t, testOptions(), objects...)
+			if result.Ready {
+				t.Fatalf("expected deployment not to be ready")
+			}
+			container := onlyContainer(t, result)
+			if container.Reason != "ImagePullBackOff" {
+				t.Errorf("Reason = %q, want ImagePullBackOff", container.Reason)
+			}
+			if len(container.Diagnosis) != 1 || container.Diagnosis[0].Message != tt.message {
+				t.Errorf("Diagnosis = %+v, want message %q", container.Diagnosis, tt.message)
+			}
+			if container.Logs != nil {
+				t.Errorf("expected no logs for an image pull failure, got %+v", container.Logs)
+			}
+		})
+	}
+}
+
+func TestValidateImagePullBackOffMultipleSecrets(t *testing.T) {
+	pod := testPod("web-1", waitingContainer("ImagePullBackOff", 0))
+	pod.Spec.ImagePullSecrets = []v1.LocalObjectReference{{Name: "dockerhub"}, {Name: "registry"}}
+	dockerhub := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dockerhub", Namespace: testNamespace},
+		Data:       map[string][]byte{v1.DockerConfigJsonKey: []byte(`{"auths":{"docker.io":{}}}`)},
+	}
+	result := validate(t, testOptions(), testDeployment(false), pod, dockerhub)
+
+	want := []Diagnosis{
+		{Reason: "ImagePullBackOff", Category: CategoryImagePull, Message: "Error getting secret registry: secrets \"registry\" not found in namspace apps, please add them"},
+		{Reason: "ImagePullBackOff", Category: CategoryImagePull, Message: "Secret dockerhub only has credentials for docker.io, but image registry.example.com/web:1.0 is pulled from registry.example.com, add credentials for registry.example.com to the secret"},
+	}
+	if got := withoutHints(onlyContainer(t, result).Diagnosis); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diagnosis = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidateImagePullPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+		image  string
+		policy v1.PullPolicy
+		want   []string
+	}{
+		{
+			name:   "never without the image on the node",
+			reason: "ErrImageNeverPull",
+			image:  "registry.example.com/web:1.0",
+			policy: v1.PullNever,
+			want:   []string{"imagePullPolicy is Never, so image registry.example.com/web:1.0 is only used if it is already present on node node-1, load it onto every node or set imagePullPolicy to IfNotPresent"},
+		},
+		{
+			name:   "always with a digest",
+			reason: "ImagePullBackOff",
+			image:  "registry.example.com/web@sha256:abc",
+			policy: v1.PullAlways,
+			want:   []string{"imagePullPolicy is Always for image registry.example.com/web@sha256:abc pinned to a digest, which never changes, IfNotPresent would not depend on the registry on every container start"},
+		},
+		{
+			name:   "latest tag",
+			reason: "ImagePullBackOff",
+			image:  "registry.example.com:5000/web",
+			policy: v1.PullIfNotPresent,
+			want:   []string{"Image registry.example.com:5000/web uses the mutable latest tag, pods may run different images and a rollout cannot be rolled back to a known image, pin a version tag or digest"},
+		},
+		{
+			name:   "pinned tag",
+			reason: "ImagePullBackOff",
+			image:  "registry.example.com/web:1.0",
+			policy: v1.PullAlways,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := testPod("web-1", waitingContainer(tt.reason, 0))
+			pod.Spec.NodeName = "node-1"
+			pod.Spec.Containers[0].Image, pod.Spec.Containers[0].ImagePullPolicy = tt.image, tt.policy
+			result := validate(t, testOptions(), testDeployment(false), pod)
+
+			var got []string
+			for _, diagnosis := range onlyContainer(t, result).Diagnosis {
+				if diagnosis.Reason == "ImagePullPolicy" {
+					got = append(got, diagnosis.Message)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pull policy warnings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCrashLoopBackOff(t *testing.T) {
+	container := waitingContainer("CrashLoopBackOff", 4)
+	container.LastTerminationState.Terminated = &v1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}
+
+	opts := testOptions()
+	opts.Logs.Match = nil
+	result := validate(t, opts, testDeployment(false), testPod("web-1", container))
+
+	got := onlyContainer(t, result)
+	want := "Container has restarted 4 times, last exit code 1 (Error)"
+	if len(got.Diagnosis) != 1 || got.Diagnosis[0].Reason != "CrashLoopBackOff" || got.Diagnosis[0].Message != want {
+		t.Errorf("Diagnosis = %+v, want CrashLoopBackOff %q", got.Diagnosis, want)
+	}
+	if got.Logs == nil || !got.Logs.Previous {
+		t.Fatalf("expected previous instance logs, got %+v", got.Logs)
+	}
+	// The fake clientset always serves "fake logs" as the container log.
+	if len(got.Logs.Lines) != 1 || got.Logs.Lines[0] != "fake logs" {
+		t.Errorf("Logs.Lines = %q, want [\"fake logs\"]", got.Logs.Lines)
+	}
+}
+
+func TestValidateFollowLogs(t *testing.T) {
+	tests := []struct {
+		maxLines int
+		followed bool
+	}{
+		{maxLines: 10, followed: true},
+		// Enough lines matched already.
+		{maxLines: 1, followed: false},
+	}
+	for _, tt := range tests {
+		opts := testOptions()
+		opts.Logs.Match, opts.Logs.MaxLines, opts.Logs.Follow = nil, tt.maxLines, 10*time.Millisecond
+		result := validate(t, opts, testDeployment(false), testPod("web-1", waitingContainer("CrashLoopBackOff", 4)))
+
+		got := onlyContainer(t, result).Logs
+		if got == nil || got.Followed != tt.followed {
+			t.Fatalf("max lines %v: expected Followed = %v, got %+v", tt.maxLines, tt.followed, got)
+		}
+		// Lines served again by the followed stream are not repeated.
+		if len(got.Lines) != 1 || got.Lines[0] != "fake logs" {
+			t.Errorf("max lines %v: Logs.Lines = %q, want [\"fake logs\"]", tt.maxLines, got.Lines)
+		}
+	}
+}
+
+func TestValidateOOMKilled(t *testing.T) {
+	container := waitingContainer("CrashLoopBackOff", 2)
+	container.LastTerminationState.Terminated = &v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}
+	pod := testPod("web-1", container)
+	pod.Spec.Containers[0].Resources.Limits = v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")}
+
+	result := validate(t, testOptions(), testDeployment(false), pod)
+
+	got := onlyContainer(t, result)
+	if len(got.Diagnosis) != 2 || got.Diagnosis[0].Reason != "OOMKilled" {
+		t.Fatalf("Diagnosis = %+v, want OOMKilled followed by CrashLoopBackOff", got.Diagnosis)
+	}
+	want := "Container was killed for exceeding its memory limit (exit code 137), memory request: not set, memory limit: 128Mi, raise \"resources.limits.memory\" in the deployment yaml or reduce the application's memory usage"
+	if got.Diagnosis[0].Message != want {
+		t.Errorf("OOMKilled message = %q, want %q", got.Diagnosis[0].Message, want)
+	}
+	wantResources := &Resources{Limits: map[string]string{"memory": "128Mi"}}
+	if !reflect.DeepEqual(got.Resources, wantResources) {
+		t.Errorf("Resources = %+v, want %+v", got.Resources, wantResources)
+	}
+}
+
+func TestValidateWithoutPods(t *testing.T) {
+	scaledDown := testDeployment(false)
+	scaledDown.Spec.Replicas = new(int32)
+	result := validate(t, testOptions(), scaledDown)
+	if !result.Ready || !strings.Contains(strings.Join(result.Status, "\n"), "web is scaled to 0 replicas") {
+		t.Errorf("expected a deployment scaled to 0 replicas to be ready, got ready %v and status %q", result.Ready, result.Status)
+	}
+
+	result = validate(t, testOptions(), testDeployment(false))
+	if result.Ready || !strings.Contains(strings.Join(result.Status, "\n"), "No pods match selector app=web in namespace apps") {
+		t.Errorf("expected a deployment without pods to report its selector, got ready %v and status %q", result.Ready, result.Status)
+	}
+}
+
+func TestValidateFlappingContainers(t *testing.T) {
+	pod := testPod("web-1", v1.ContainerStatus{
+		Name:                 "app",
+		Ready:                true,
+		RestartCount:         7,
+		State:                v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+		LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+	})
+	steady := testPod("web-2", v1.ContainerStatus{Name: "app", Ready: true, RestartCount: 1, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}})
+	opts := testOptions()
+	opts.RestartThreshold = 3
+	result := validate(t, opts, testDeployment(true), pod, steady)
+
+	want := []FlappingContainer{{Pod: "web-1", Container: "app", RestartCount: 7, LastReason: "OOMKilled", LastExitCode: 137}}
+	if !reflect.DeepEqual(result.Flapping, want) {
+		t.Errorf("Flapping = %+v, want %+v", result.Flapping, want)
+	}
+}
+
+func TestValidateSelector(t *testing.T) {
+	pod := testPod("web-1", v1.ContainerStatus{Name: "app", Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}})
+	pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+
+	opts := testOptions()
+	opts.Name, opts.Selector = "", "app=web"
+	result := validate(t, opts, pod)
+	if !result.Ready {
+		t.Fatalf("expected selected pods to be ready, status %v", result.Status)
+	}
+	if result.Kind != "Pods" || result.Name != "app=web" {
+		t.Errorf("unexpected result identity %v/%v", result.Kind, result.Name)
+	}
+}
+
+func TestValidateSkipsCompletedPods(t *testing.T) {
+	completed := testPod("job-1", v1.ContainerStatus{Name: "app", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}}})
+	completed.Status.Phase = v1.PodSucceeded
+	failing := testPod("web-1", waitingContainer("CrashLoopBackOff", 3))
+
+	opts := testOptions()
+	opts.Name, opts.Selector = "", "app=web"
+	result := validate(t, opts, completed)
+	if !result.Ready {
+		t.Fatalf("expected completed pods to be ready, status %v", result.Status)
+	}
+
+	result = validate(t, opts, completed, failing)
+	if result.Ready {
+		t.Fatalf("expected the failing pod to fail the validation")
+	}
+	if want := []string{"Pods: 0/1 ready, 1 completed"}; !reflect.DeepEqual(result.Status, want) {
+		t.Errorf("Status = %v, want %v", result.Status, want)
+	}
+	for _, pod := range result.Pods {
+		if completed := pod.Name == "job-1"; pod.Completed != completed || completed && len(pod.Containers) > 0 {
+			t.Errorf("pod %v: Completed = %v with containers %+v", pod.Name, pod.Completed, pod.Containers)
+		}
+	}
+}
+
+func TestValidateSummary(t *testing.T) {
+	ready := testPod("web-1", v1.ContainerStatus{Name: "app", Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}})
+	ready.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	opts := testOptions()
+	opts.Logs.Match = nil
+	result := validate(t, opts, testDeployment(false), ready,
+		testPod("web-2", waitingContainer("CrashLoopBackOff", 3)),
+		testPod("web-3", waitingContainer("CrashLoopBackOff", 1)),
+		testPod("web-4", waitingContainer("ImagePullBackOff", 0)))
+
+	want := Summary{Total: 4, Ready: 1, Failing: 3, Reasons: []ReasonCount{{"CrashLoopBackOff", 2}, {"ImagePullBackOff", 1}}}
+	if !reflect.DeepEqual(result.Summary, want) {
+		t.Errorf("Summary = %+v, want %+v", result.Summary, want)
+	}
+	if got, want := result.Summary.String(), "1/4 pods ready, 3 failing (2 CrashLoopBackOff, 1 ImagePullBackOff)"; got != want {
+		t.Errorf("Summary.String() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateMixedImages(t *testing.T) {
+	running := func(name, imageID string) *v1.Pod {
+		pod := testPod(name, v1.ContainerStatus{Name: "app", Image: "registry.example.com/web:latest", ImageID: imageID, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}})
+		pod.Status.Phase = v1.PodRunning
+		return pod
+	}
+	result := validate(t, testOptions(), testDeployment(false),
+		running("web-1", "registry.example.com/web@sha256:aaa"),
+		running("web-2", "registry.example.com/web@sha256:bbb"),
+		running("web-3", "registry.example.com/web@sha256:aaa"),
+		testPod("web-4", waitingContainer("ImagePullBackOff", 0)))
+
+	want := []ImageVersion{
+		{Container: "app", Image: "registry.example.com/web:latest", ImageID: "registry.example.com/web@sha256:aaa", Pods: []string{"web-1", "web-3"}},
+		{Container: "app", Image: "registry.example.com/web:latest", ImageID: "registry.example.com/web@sha256:bbb", Pods: []string{"web-2"}},
+	}
+	if !reflect.DeepEqual(result.Images, want) {
+		t.Errorf("Images = %+v, want %+v", result.Images, want)
+	}
+	if mixed := result.MixedImages(); !reflect.DeepEqual(mixed, []string{"app"}) {
+		t.Errorf("MixedImages() = %v, want [app]", mixed)
+	}
+	if digest := ImageDigest(want[0].ImageID); digest != "sha256:aaa" {
+		t.Errorf("ImageDigest() = %q, want sha256:aaa", digest)
+	}
+}
+
+func TestValidateListsEveryPageOfPods(t *testing.T) {
+	ready := testPod("web-1", v1.ContainerStatus{Name: "app", Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}})
+	ready.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	failing := testPod("web-2", waitingContainer("CrashLoopBackOff", 3))
+	client := fake.NewSimpleClientset()
+	lists := 0
+	client.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		// The fake clientset does not pass the continue token on, so every
+		// first list of a check returns the first page.
+		lists++
+		if lists%2 == 1 {
+			return true, &v1.PodList{ListMeta: metav1.ListMeta{Continue: "page-2"}, Items: []v1.Pod{*ready}}, nil
+		}
+		return true, &v1.PodList{Items: []v1.Pod{*failing}}, nil
+	})
+
+	opts := testOptions()
+	opts.Name, opts.Selector = "", "app=web"
+	result, err := New(client, opts).Validate(context.Background())
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if result.Ready || result.Summary.Total != 2 {
+		t.Errorf("expected both pages of pods to be validated, got ready %v and summary %v", result.Ready, result.Summary)
+	}
+}
+
+func TestValidateWarningEvents(t *testing.T) {
+	pod := testPod("web-1", waitingContainer("ContainerCreating", 0))
+	event := &v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web-1.1", Namespace: testNamespace},
+		InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "web-1", Namespace: testNamespace},
+		Type:           v1.EventTypeWarning,
+		Reason:         "FailedMount",
+		Message:        "MountVolume.SetUp failed for volume \"config\"",
+		Count:          3,
+	}
+	opts := testOptions()
+	opts.Logs.Match = nil
+	result := validate(t, opts, testDeployment(false), pod, event)
+
+	if len(result.Pods) != 1 {
+		t.Fatalf("expected one pod, got %d", len(result.Pods))
+	}
+	want := []Event{{Reason: "FailedMount", Message: event.Message, Count: 3}}
+	if !reflect.DeepEqual(result.Pods[0].Events, want) {
+		t.Errorf("Events = %+v, want %+v", result.Pods[0].Events, want)
+	}
+}
+
+func TestValidateUnschedulablePod(t *testing.T) {
+	pod := testPod("web-1", v1.ContainerStatus{})
+	pod.Status.ContainerStatuses = nil
+	pod.Status.Conditions = []v1.PodCondition{{
+		Type:    v1.PodScheduled,
+		Status:  v1.ConditionFalse,
+		Reason:  "Unschedulable",
+		Message: "0/3 nodes are available: 3 Insufficient cpu.",
+	}}
+	result := validate(t, testOptions(), testDeployment(false), pod)
+
+	if len(result.Pods) != 1 || len(result.Pods[0].Diagnosis) != 1 {
+		t.Fatalf("expected one pod level diagnosis, got %+v", result.Pods)
+	}
+	got := result.Pods[0].Diagnosis[0]
+	if got.Reason != "FailedScheduling" || !strings.Contains(got.Message, "3 Insufficient cpu") {
+		t.Errorf("Diagnosis = %+v, want FailedScheduling with the scheduler message", got)
+	}
+}
+
+func TestValidateImagePullBackOffWithoutSecrets(t *testing.T) {
+	container := waitingContainer("ErrImagePull", 0)
+	container.Image = "nginx:latset"
+	pod := testPod("web-1", container)
+	pod.Spec.ImagePullSecrets = nil
+
+	result := validate(t, testOptions(), testDeployment(false), pod)
+
+	got := onlyContainer(t, result)
+	if len(got.Diagnosis) != 1 || !strings.Contains(got.Diagnosis[0].Message, "without any imagePullSecrets") || !strings.Contains(got.Diagnosis[0].Message, "nginx:latset") {
+		t.Errorf("Diagnosis = %+v, want a hint about the missing pull secret and the image name", got.Diagnosis)
+	}
+}
+
+func TestValidateWatch(t *testing.T) {
+	client := fake.NewSimpleClientset(testDeployment(false))
+	watcher := watch.NewFake()
+	client.PrependWatchReactor("deployments", k8stesting.DefaultWatchReactor(watcher, nil))
+
+	opts := testOptions()
+	opts.Watch = true
+	opts.Timeout = time.Minute
+	opts.Interval = time.Minute
+	go watcher.Modify(testDeployment(true))
+
+	result, err := New(client, opts).Validate(context.Background())
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !result.Ready {
+		t.Errorf("expected the watch to observe the deployment becoming available")
+	}
+}
+
+func TestDeploymentStatus(t *testing.T) {
+	deployment := testDeployment(false)
+	replicas := int32(3)
+	deployment.Spec.Replicas = &replicas
+	deployment.Status.ReadyReplicas, deployment.Status.UpdatedReplicas, deployment.Status.AvailableReplicas = 2, 3, 2
+	deployment.Status.Conditions = append(deployment.Status.Conditions, Appsv1.DeploymentCondition{
+		Type:    Appsv1.DeploymentProgressing,
+		Status:  v1.ConditionTrue,
+		Message: "ReplicaSet \"web-5d4f\" is progressing.",
+	})
+
+	status := deploymentStatus(deployment, Condition{})
+	want := []string{
+		"Deployment web: 2/3 replicas ready, 3 updated, 2 available",
+		"Progressing: ReplicaSet \"web-5d4f\" is progressing.",
+		"Waiting for deployment \"web\" rollout to finish: 2 of 3 updated replicas are available...",
+	}
+	if status.ready || !reflect.DeepEqual(status.status, want) {
+		t.Errorf("deploymentStatus() = ready %v, status %q, want not ready, status %q", status.ready, status.status, want)
+	}
+}
+
+func TestRolloutStatus(t *testing.T) {
+	tests := []struct {
+		generation, observed      int64
+		total, updated, available int32
+		ready                     bool
+		status                    string
+	}{
+		{generation: 2, observed: 2, total: 3, updated: 1, available: 1, status: "1 out of 3 new replicas have been updated..."},
+		{generation: 2, observed: 2, total: 4, updated: 3, available: 3, status: "1 old replicas are pending termination..."},
+		{generation: 2, observed: 2, total: 3, updated: 3, available: 2, status: "2 of 3 updated replicas are available..."},
+		{generation: 2, observed: 2, total: 3, updated: 3, available: 3, ready: true, status: "deployment \"web\" successfully rolled out"},
+	}
+	for _, tt := range tests {
+		deployment := testDeployment(true)
+		replicas := int32(3)
+		deployment.Spec.Replicas = &replicas
+		deployment.Generation, deployment.Status.ObservedGeneration = tt.generation, tt.observed
+		deployment.Status.Replicas, deployment.Status.UpdatedReplicas, deployment.Status.AvailableReplicas = tt.total, tt.updated, tt.available
+		status := deploymentStatus(deployment, Condition{})
+		if status.ready != tt.ready || !strings.Contains(strings.Join(status.status, "\n"), tt.status) {
+			t.Errorf("deploymentStatus() = ready %v, status %q, want ready %v with %q", status.ready, status.status, tt.ready, tt.status)
+		}
+	}
+}
+
+func TestDeploymentStatusWaitsForCondition(t *testing.T) {
+	tests := []struct {
+		wanted string
+		ready  bool
+		status string
+	}{
+		{wanted: "Progressing=True", ready: true, status: "Deployment web has condition Progressing=True"},
+		{wanted: "Progressing=False", status: "Deployment web does not have condition Progressing=False, it has Available=False, Progressing=True"},
+		{wanted: "example.com/Verified=True", status: "does not have condition example.com/Verified=True"},
+	}
+	for _, tt := range tests {
+		deployment := testDeployment(false)
+		deployment.Status.Conditions = append(deployment.Status.Conditions, Appsv1.DeploymentCondition{Type: Appsv1.DeploymentProgressing, Status: v1.ConditionTrue})
+		wanted, err := ParseCondition(tt.wanted)
+		if err != nil {
+			t.Fatalf("ParseCondition(%q) error = %v", tt.wanted, err)
+		}
+		status := deploymentStatus(deployment, wanted)
+		if status.ready != tt.ready || !strings.Contains(strings.Join(status.status, "\n"), tt.status) {
+			t.Errorf("%v: deploymentStatus() = ready %v, status %q, want ready %v with %q", tt.wanted, status.ready, status.status, tt.ready, tt.status)
+		}
+	}
+	if _, err := ParseCondition("Progressing"); err == nil {
+		t.Errorf("expected ParseCondition to reject a condition without a status")
+	}
+}
+
+func TestValidateDeploymentBecomesAvailable(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	gets := 0
+	client.PrependReactor("get", "deployments", func(k8stesting.Action) (bool, runtime.Object, error) {
+		gets++
+		return true, testDeployment(gets > 2), nil
+	})
+
+	opts := testOptions()
+	opts.Timeout = time.Second
+	result, err := New(client, opts).Validate(context.Background())
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !result.Ready {
+		t.Errorf("expected the deployment becoming available during the wait to be detected")
+	}
+	if gets != 3 {
+		t.Errorf("expected the deployment to be fetched on every poll, got %d gets", gets)
+	}
+}
+
+func TestValidateProgressDeadlineExceeded(t *testing.T) {
+	deployment := testDeployment(false)
+	deployment.Status.Conditions = append(deployment.Status.Conditions, Appsv1.DeploymentCondition{
+		Type:    Appsv1.DeploymentProgressing,
+		Status:  v1.ConditionFalse,
+		Reason:  "ProgressDeadlineExceeded",
+		Message: "ReplicaSet \"web-5d4f\" has timed out progressing.",
+	})
+	client := fake.NewSimpleClientset(deployment, testPod("web-1", waitingContainer("ImagePullBackOff", 0)))
+	gets := 0
+	client.PrependReactor("get", "deployments", func(k8stesting.Action) (bool, runtime.Object, error) {
+		gets++
+		return false, nil, nil
+	})
+
+	opts := testOptions()
+	opts.Timeout = time.Minute
+	result, err := New(client, opts).Validate(context.Background())
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if result.Ready || gets != 1 {
+		t.Errorf("expected validation to fail without waiting, got ready %v after %d gets", result.Ready, gets)
+	}
+	if len(result.Pods) != 1 {
+		t.Errorf("expected the blocking pod to be diagnosed, got %+v", result.Pods)
+	}
+	if !strings.Contains(strings.Join(result.Status, "\n"), "exceeded its progress deadline") {
+		t.Errorf("expected the status to report the progress deadline, got %q", result.Status)
+	}
+}
+
+func TestValidateReadinessProbeFailure(t *testing.T) {
+	container := v1.ContainerStatus{Name: "app", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}
+	pod := testPod("web-1", container)
+	pod.Status.Phase = v1.PodRunning
+	pod.Spec.Containers[0].ReadinessProbe = &v1.Probe{ProbeHandler: v1.ProbeHandler{
+		HTTPGet: &v1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt32(8080)},
+	}}
+	event := &v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web-1.1", Namespace: testNamespace},
+		InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "web-1", Namespace: testNamespace, FieldPath: "spec.containers{app}"},
+		Type:           v1.EventTypeWarning,
+		Reason:         "Unhealthy",
+		Message:        "Readiness probe failed: HTTP probe failed with statuscode: 503",
+		Count:          12,
+	}
+	result := validate(t, testOptions(), testDeployment(false), pod, event)
+
+	want := []Diagnosis{{
+		Reason:   "ReadinessProbeFailed",
+		Category: CategoryProbeFailure,
+		Message:  "Readiness probe httpGet /healthz on port 8080 is failing (x12): HTTP probe failed with statuscode: 503",
+	}}
+	if got := withoutHints(onlyContainer(t, result).Diagnosis); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diagnosis = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidateInitContainerCrashLoop(t *testing.T) {
+	pod := testPod("web-1", waitingContainer("PodInitializing", 0))
+	initContainer := waitingContainer("CrashLoopBackOff", 4)
+	initContainer.Name = "migrate"
+	pod.Spec.InitContainers = []v1.Container{{Name: "migrate", Image: "registry.example.com/migrate:1.0"}}
+	pod.Status.InitContainerStatuses = []v1.ContainerStatus{
+		{Name: "setup", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed"}}},
+		initContainer,
+	}
+	result := validate(t, testOptions(), testDeployment(false), pod)
+
+	if len(result.Pods) != 1 || len(result.Pods[0].Containers) != 2 {
+		t.Fatalf("expected the failing init container and the main container, got %+v", result.Pods)
+	}
+	containers := result.Pods[0].Containers
+	if !containers[0].Init || containers[0].Name != "migrate" || containers[0].Reason != "CrashLoopBackOff" || containers[0].Logs == nil {
+		t.Errorf("expected the init container to be diagnosed first with its logs, got %+v", containers[0])
+	}
+	if containers[1].Init || containers[1].Logs != nil || len(containers[1].Diagnosis) != 1 || containers[1].Diagnosis[0].Reason != "PodInitializing" {
+		t.Errorf("expected the main container to wait for the init containers, got %+v", containers[1])
+	}
+}
+
+func TestValidateNativeSidecarCrashLoop(t *testing.T) {
+	pod := testPod("web-1", waitingContainer("PodInitializing", 0))
+	always := v1.ContainerRestartPolicyAlways
+	pod.Spec.InitContainers = []v1.Container{{Name: "proxy", RestartPolicy: &always}, {Name: "log-shipper", RestartPolicy: &always}}
+	sidecar := waitingContainer("CrashLoopBackOff", 5)
+	sidecar.Name = "proxy"
+	pod.Status.InitContainerStatuses = []v1.ContainerStatus{
+		sidecar,
+		{Name: "log-shipper", Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+	}
+	opts := testOptions()
+	opts.Logs.Match = nil
+	result := validate(t, opts, testDeployment(false), pod)
+
+	if len(result.Pods) != 1 || len(result.Pods[0].Containers) != 2 {
+		t.Fatalf("expected the failing sidecar and the main container, got %+v", result.Pods)
+	}
+	got := result.Pods[0].Containers[0]
+	if !got.Sidecar || !got.Init || got.Name != "proxy" {
+		t.Fatalf("expected the crash-looping sidecar first, got %+v", got)
+	}
+	last := got.Diagnosis[len(got.Diagnosis)-1]
+	if last.Reason != "SidecarNotReady" || !strings.Contains(last.Message, "is CrashLoopBackOff after 5 restarts") {
+		t.Errorf("expected a SidecarNotReady diagnosis, got %+v", got.Diagnosis)
+	}
+}
+
+func TestValidateNodeLost(t *testing.T) {
+	pod := testPod("web-1", v1.ContainerStatus{Name: "app", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "ContainerStatusUnknown", ExitCode: 137}}})
+	pod.Spec.NodeName = "node-1"
+	pod.Status.Phase = v1.PodFailed
+	result := validate(t, testOptions(), testDeployment(false), pod)
+
+	if len(result.Pods) != 1 || len(result.Pods[0].Diagnosis) == 0 || result.Pods[0].Diagnosis[0].Reason != "NodeLost" {
+		t.Fatalf("expected a NodeLost diagnosis, got %+v", result.Pods)
+	}
+	if logs := onlyContainer(t, result).Logs; logs != nil {
+		t.Errorf("expected no logs to be fetched from a lost node, got %+v", logs)
+	}
+}
+
+func TestValidateGroupsIdenticalErrors(t *testing.T) {
+	result := validate(t, testOptions(), testDeployment(false),
+		testPod("web-1", waitingContainer("CrashLoopBackOff", 2)),
+		testPod("web-2", waitingContainer("CrashLoopBackOff", 2)),
+		testPod("web-3", waitingContainer("CreateContainerConfigError", 0)),
+	)
+
+	want := []ErrorGroup{{Container: "app", Reason: "CrashLoopBackOff", Pods: []string{"web-1", "web-2"}}}
+	if !reflect.DeepEqual(result.ErrorGroups, want) {
+		t.Errorf("ErrorGroups = %+v, want %+v", result.ErrorGroups, want)
+	}
+	if result.ErrorGroup("web-3", "app") != nil {
+		t.Errorf("expected web-3 not to share its error")
+	}
+}
+
+// proxyResponse is the response of a fake service proxy request.
+type proxyResponse struct {
+	err error
+}
+
+func (r proxyResponse) DoRaw(context.Context) ([]byte, error) {
+	return nil, r.err
+}
+
+func (r proxyResponse) Stream(context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), r.err
+}
+
+func TestValidateServiceProbe(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantReady bool
+		wantCode  int
+	}{
+		{name: "healthy", wantReady: true, wantCode: 200},
+		{name: "server error", err: apierrors.NewGenericServerResponse(500, "get", schema.GroupResource{Resource: "services"}, "web", "boom", 0, false), wantCode: 500},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+				Spec: v1.ServiceSpec{
+					Selector: map[string]string{"app": "web"},
+					Ports:    []v1.ServicePort{{Name: "http", Port: 80}},
+				},
+			}
+			pod := testPod("web-1", v1.ContainerStatus{Name: "app", Ready: true})
+			client := fake.NewSimpleClientset(testDeployment(true), pod, service)
+			var path string
+			client.PrependProxyReactor("services", func(action k8stesting.Action) (bool, restclient.ResponseWrapper, error) {
+				path = action.(k8stesting.ProxyGetAction).GetPath()
+				return true, proxyResponse{err: test.err}, nil
+			})
+
+			opts := testOptions()
+			opts.ServiceProbe = &ServiceProbe{Path: "/healthz"}
+			result, err := New(client, opts).Validate(context.Background())
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if result.Ready != test.wantReady || result.Service == nil || result.Service.StatusCode != test.wantCode {
+				t.Fatalf("expected ready %v with status %v, got ready %v, service %+v", test.wantReady, test.wantCode, result.Ready, result.Service)
+			}
+			if path != "/healthz" || result.Service.Name != "web" || result.Service.Port != "http" {
+				t.Errorf("expected GET of /healthz on web:http, got %v on %+v", path, result.Service)
+			}
+		})
+	}
+}
+
+func TestValidateService(t *testing.T) {
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+		Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+	}
+	endpoint := func(uid types.UID, ready bool) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses:  []string{string(uid)},
+			Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+			TargetRef:  &v1.ObjectReference{Kind: "Pod", UID: uid},
+		}
+	}
+	slice := func(name string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: map[string]string{discoveryv1.LabelServiceName: "web"}},
+			Endpoints:  endpoints,
+		}
+	}
+	opts := testOptions()
+	opts.Kind, opts.Name = "service", "web"
+
+	result := validate(t, opts, service,
+		slice("web-ipv4", endpoint("uid-1", true), endpoint("uid-2", false)),
+		slice("web-ipv6", endpoint("uid-1", true)),
+		testPod("web-2", waitingContainer("CrashLoopBackOff", 3)))
+	if result.Ready || result.Kind != "Service" {
+		t.Fatalf("expected a not ready Service, got ready %v kind %v", result.Ready, result.Kind)
+	}
+	if want := "Service web: 1 ready, 1 not ready endpoints"; len(result.Status) == 0 || result.Status[0] != want {
+		t.Errorf("Status = %q, want %q", result.Status, want)
+	}
+	if got := onlyContainer(t, result); got.Reason != "CrashLoopBackOff" {
+		t.Errorf("expected the pod behind the Service to be diagnosed, got %+v", got)
+	}
+
+	if result := validate(t, opts, service, slice("web-ipv4", endpoint("uid-1", true), endpoint("uid-2", true))); !result.Ready {
+		t.Errorf("expected a Service with every endpoint ready to be ready, got %q", result.Status)
+	}
+
+	// Without access to EndpointSlices the Endpoints are counted.
+	client := fake.NewSimpleClientset(service, &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+		Subsets: []v1.EndpointSubset{{
+			Addresses:         []v1.EndpointAddress{{IP: "10.0.0.1"}},
+			NotReadyAddresses: []v1.EndpointAddress{{IP: "10.0.0.2"}, {IP: "10.0.0.3"}},
+		}},
+	})
+	client.PrependReactor("list", "endpointslices", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "discovery.k8s.io", Resource: "endpointslices"}, "", fmt.Errorf("denied"))
+	})
+	result, err := New(client, opts).Validate(context.Background())
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if want := "Service web: 1 ready, 2 not ready endpoints"; result.Ready || len(result.Status) == 0 || result.Status[0] != want {
+		t.Errorf("expected a not ready Service with Status %q, got ready %v, %q", want, result.Ready, result.Status)
+	}
+}
+
+func TestValidateMultipleContainersKeepOrder(t *testing.T) {
+	pod := testPod("web-1", waitingContainer("CrashLoopBackOff", 1))
+	var names []string
+	for _, name := range []string{"proxy", "logger", "metrics", "cache", "worker"} {
+		container := waitingContainer("CrashLoopBackOff", 1)
+		container.Name = name
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: name})
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, container)
+	}
+	for _, container := range pod.Status.ContainerStatuses {
+		names = append(names, container.Name)
+	}
+	result := validate(t, testOptions(), testDeployment(false), pod)
+
+	var got []string
+	for _, container := range result.Pods[0].Containers {
+		if container.Logs == nil {
+			t.Errorf("expected logs for container %v", container.Name)
+		}
+		got = append(got, container.Name)
+	}
+	if !reflect.DeepEqual(got, names) {
+		t.Errorf("containers = %v, want the pod status order %v", got, names)
+	}
+}
+
+func TestValidateFollowEvents(t *testing.T) {
+	client := fake.NewSimpleClientset(testDeployment(false), testPod("web-1", waitingContainer("ContainerCreating", 0)))
+	watcher := watch.NewFake()
+	client.PrependWatchReactor("events", k8stesting.DefaultWatchReactor(watcher, nil))
+	go watcher.Add(&v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web-1.1", Namespace: testNamespace},
+		InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "web-1", Namespace: testNamespace},
+		Type:           v1.EventTypeWarning,
+		Reason:         "FailedMount",
+		Message:        "MountVolume.SetUp failed for volume \"config\"",
+	})
+
+	var logs strings.Builder
+	opts := testOptions()
+	opts.FollowEvents = true
+	opts.Timeout = 300 * time.Millisecond
+	opts.Interval = 100 * time.Millisecond
+	v := New(client, opts)
+	v.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	if _, err := v.Validate(context.Background()); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !strings.Contains(logs.String(), `Event FailedMount on pod web-1: MountVolume.SetUp failed for volume \"config\"`) {
+		t.Errorf("expected the event to be logged while waiting, got %q", logs.String())
+	}
+}
+
+func TestValidateCreateContainerError(t *testing.T) {
+	container := waitingContainer("CreateContainerError", 0)
+	container.State.Waiting.Message = `failed to create containerd task: exec: "/app/start.sh": permission denied`
+	pod := testPod("web-1", container)
+	pod.Spec.Containers[0].Command = []string{"/app/start.sh"}
+	result := validate(t, testOptions(), testDeployment(false), pod)
+
+	got := onlyContainer(t, result)
+	want := `Container could not be created: failed to create containerd task: exec: "/app/start.sh": permission denied, check the command ["/app/start.sh"] and args [] of the container`
+	if len(got.Diagnosis) != 1 || got.Diagnosis[0].Message != want || got.Logs != nil {
+		t.Errorf("Diagnosis = %+v, logs %+v, want %q without logs", got.Diagnosis, got.Logs, want)
+	}
+}
+
+func TestValidateRunContainerErrorMount(t *testing.T) {
+	container := waitingContainer("RunContainerError", 2)
+	container.State.Waiting.Message = `failed to create containerd task: error mounting "/var/lib/kubelet/pods/1/volumes/config" to rootfs at "/etc/app": not a directory`
+	pod := testPod("web-1", container)
+	pod.Spec.Containers[0].VolumeMounts = []v1.VolumeMount{
+		{Name: "data", MountPath: "/data"},
+		{Name: "config", MountPath: "/etc/app"},
+	}
+	pod.Spec.Volumes = []v1.Volume{
+		{Name: "data", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+		{Name: "config", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/etc/app.conf"}}},
+	}
+	result := validate(t, testOptions(), testDeployment(false), pod)
+
+	got := onlyContainer(t, result)
+	want := container.State.Waiting.Message + ", volume mounts: data at /data (emptyDir), config at /etc/app (hostPath /etc/app.conf), the failing one is config (hostPath /etc/app.conf)"
+	if len(got.Diagnosis) != 1 || got.Diagnosis[0].Reason != "RunContainerError" || got.Diagnosis[0].Message != "Container could not be started: "+want {
+		t.Errorf("Diagnosis = %+v, want RunContainerError %q", got.Diagnosis, want)
+	}
+}
+
+func TestValidateReplicaSet(t *testing.T) {
+	replicas := int32(2)
+	replicaSet := &Appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-5d4f", Namespace: testNamespace},
+		Spec: Appsv1.ReplicaSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		Status: Appsv1.ReplicaSetStatus{ReadyReplicas: 1, AvailableReplicas: 1},
+	}
+	opts := testOptions()
+	opts.Kind, opts.Name = "replicaset", "web-5d4f"
+	result := validate(t, opts, replicaSet, testPod("web-5d4f-1", waitingContainer("CrashLoopBackOff", 3)))
+
+	if result.Ready || result.Kind != "ReplicaSet" {
+		t.Fatalf("expected a not ready ReplicaSet, got ready %v kind %v", result.Ready, result.Kind)
+	}
+	if want := "ReplicaSet web-5d4f: 1/2 replicas ready, 1 available"; len(result.Status) == 0 || result.Status[0] != want {
+		t.Errorf("Status = %q, want %q", result.Status, want)
+	}
+	if got := onlyContainer(t, result); got.Reason != "CrashLoopBackOff" {
+		t.Errorf("expected the pod of the ReplicaSet to be diagnosed, got %+v", got)
+	}
+
+	replicaSet.Status.ReadyReplicas = 2
+	if result := validate(t, opts, replicaSet); !result.Ready {
+		t.Errorf("expected a ReplicaSet with every replica ready to be ready")
+	}
+}
+
+func TestValidateCronJob(t *testing.T) {
+	controller := true
+	scheduled := metav1.NewTime(time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC))
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: testNamespace, UID: "cronjob-uid"},
+		Spec:       batchv1.CronJobSpec{Schedule: "0 3 * * *"},
+		Status:     batchv1.CronJobStatus{LastScheduleTime: &scheduled},
+	}
+	job := func(name string, uid types.UID, created time.Time, condition batchv1.JobConditionType) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         testNamespace,
+				UID:               uid,
+				CreationTimestamp: metav1.NewTime(created),
+				OwnerReferences:   []metav1.OwnerReference{{Kind: "CronJob", Name: "backup", UID: cronJob.UID, Controller: &controller}},
+			},
+			Spec:   batchv1.JobSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+			Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: condition, Status: v1.ConditionTrue}}},
+		}
+	}
+	pod := func(name string, owner types.UID, container v1.ContainerStatus) *v1.Pod {
+		pod := testPod(name, container)
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: "Job", UID: owner, Controller: &controller}}
+		return pod
+	}
+	opts := testOptions()
+	opts.Kind, opts.Name = "cronjob", "backup"
+
+	result := validate(t, opts, cronJob,
+		job("backup-1", "old-uid", scheduled.Add(-24*time.Hour), batchv1.JobFailed),
+		job("backup-2", "new-uid", scheduled.Time, batchv1.JobComplete))
+	if !result.Ready || result.Kind != "CronJob" {
+		t.Fatalf("expected a ready CronJob after its last run succeeded, got ready %v kind %v", result.Ready, result.Kind)
+	}
+	status := strings.Join(result.Status, "\n")
+	for _, want := range []string{`CronJob backup: schedule "0 3 * * *", last scheduled 2024-05-01T03:00:00Z, 0 active`, "The last run backup-2 of CronJob backup succeeded"} {
+		if !strings.Contains(status, want) {
+			t.Errorf("Status = %q, want it to contain %q", result.Status, want)
+		}
+	}
+
+	result = validate(t, opts, cronJob,
+		job("backup-1", "old-uid", scheduled.Add(-24*time.Hour), batchv1.JobComplete),
+		job("backup-2", "new-uid", scheduled.Time, batchv1.JobFailed),
+		pod("backup-1-a", "old-uid", waitingContainer("ImagePullBackOff", 0)),
+		pod("backup-2-a", "new-uid", waitingContainer("CrashLoopBackOff", 6)))
+	if result.Ready {
+		t.Fatalf("expected a CronJob whose last run failed not to be ready")
+	}
+	if !strings.Contains(strings.Join(result.Status, "\n"), "The last run backup-2 of CronJob backup failed") {
+		t.Errorf("expected the status to report the failed run, got %q", result.Status)
+	}
+	if len(result.Pods) != 1 || result.Pods[0].Name != "backup-2-a" {
+		t.Fatalf("expected only the pod of the last Job to be diagnosed, got %+v", result.Pods)
+	}
+
+	// The Jobs are listed by the labels of the JobTemplate.
+	cronJob.Spec.JobTemplate.Labels = map[string]string{"job": "backup"}
+	labelled := job("backup-2", "new-uid", scheduled.Time, batchv1.JobComplete)
+	labelled.Labels = cronJob.Spec.JobTemplate.Labels
+	client := fake.NewSimpleClientset(cronJob, labelled, job("backup-3", "other-uid", scheduled.Add(time.Hour), batchv1.JobFailed))
+	result, err := New(client, opts).Validate(context.Background())
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !result.Ready {
+		t.Errorf("expected the Job without the JobTemplate labels to be skipped, got %q", result.Status)
+	}
+	for _, action := range client.Actions() {
+		if list, ok := action.(k8stesting.ListAction); ok && action.GetResource().Resource == "jobs" {
+			if got := list.GetListRestrictions().Labels.String(); got != "job=backup" {
+				t.Errorf("jobs listed with selector %q, want %q", got, "job=backup")
+			}
+		}
+	}
+}
+
+func TestValidateOnlyDiagnosesCurrentReplicaSet(t *testing.T) {
+	deployment := testDeployment(false)
+	deployment.UID = "deployment-uid"
+	controller := true
+	replicaSet := func(name string, uid types.UID, revision string) *Appsv1.ReplicaSet {
+		return &Appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       testNamespace,
+			UID:             uid,
+			Labels:          map[string]string{"app": "web"},
+			Annotations:     map[string]string{"deployment.kubernetes.io/revision": revision},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: deployment.UID, Controller: &controller}},
+		}}
+	}
+	pod := func(name string, owner types.UID, container v1.ContainerStatus) *v1.Pod {
+		pod := testPod(name, container)
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", UID: owner, Controller: &controller}}
+		return pod
+	}
+	result := validate(t, testOptions(), deployment,
+		replicaSet("web-old", "old-uid", "9"), replicaSet("web-new", "new-uid", "10"),
+		pod("web-old-1", "old-uid", waitingContainer("CrashLoopBackOff", 4)),
+		pod("web-new-1", "new-uid", waitingContainer("ImagePullBackOff", 0)))
+
+	if len(result.Pods) != 1 || result.Pods[0].Name != "web-new-1" {
+		t.Fatalf("expected only the pod of the current ReplicaSet to be diagnosed, got %+v", result.Pods)
+	}
+	if !strings.Contains(strings.Join(result.Status, "\n"), "Current ReplicaSet web-new (revision 10)") {
+		t.Errorf("expected the status to name the current ReplicaSet, got %q", result.Status)
+	}
+}
+
+func TestValidateRevision(t *testing.T) {
+	tests := []struct {
+		revision int64
+		ready    bool
+		status   string
+	}{
+		{revision: 3, ready: true, status: "Deployment web is at revision 3"},
+		{revision: 4, status: "Deployment web is at revision 3, waiting for revision 4"},
+		{revision: 2, status: "Revision mismatch: Deployment web is at revision 3 instead of 2"},
+	}
+	for _, tt := range tests {
+		deployment := testDeployment(true)
+		deployment.Annotations = map[string]string{"deployment.kubernetes.io/revision": "3"}
+		opts := testOptions()
+		opts.Revision = tt.revision
+		result := validate(t, opts, deployment)
+		if result.Ready != tt.ready {
+			t.Errorf("revision %v: Ready = %v, want %v", tt.revision, result.Ready, tt.ready)
+		}
+		if status := strings.Join(result.Status, "\n"); !strings.Contains(status, tt.status) {
+			t.Errorf("revision %v: expected the status to contain %q, got %q", tt.revision, tt.status, status)
+		}
+	}
+}
+
+func TestValidatePaused(t *testing.T) {
+	tests := []struct {
+		available bool
+		ready     bool
+		paused    bool
+		status    string
+	}{
+		{available: false, paused: true, status: "kubectl rollout resume deployment/web -n apps"},
+		{available: true, ready: true, status: "changes to its pod template will not roll out until it is resumed"},
+	}
+	for _, tt := range tests {
+		deployment := testDeployment(tt.available)
+		deployment.Spec.Paused = true
+		opts := testOptions()
+		// A paused deployment is not waited for.
+		opts.Timeout = time.Hour
+		result := validate(t, opts, deployment)
+		if result.Ready != tt.ready || result.Paused != tt.paused {
+			t.Errorf("available %v: Ready, Paused = %v, %v, want %v, %v", tt.available, result.Ready, result.Paused, tt.ready, tt.paused)
+		}
+		if status := strings.Join(result.Status, "\n"); !strings.Contains(status, tt.status) {
+			t.Errorf("available %v: expected the status to contain %q, got %q", tt.available, tt.status, status)
+		}
+	}
+}
+
+func TestValidateUnhealthyNode(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: v1.NodeStatus{Conditions: []v1.NodeCondition{
+			{Type: v1.NodeReady, Status: v1.ConditionTrue},
+			{Type: v1.NodeMemoryPressure, Status: v1.ConditionTrue, Message: "kubelet has insufficient memory available"},
+			{Type: v1.NodeDiskPressure, Status: v1.ConditionFalse},
+		}},
+	}
+	healthy := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}, Status: v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}}}
+	var pods []runtime.Object
+	for _, name := range []string{"web-1", "web-2", "web-3"} {
+		pod := testPod(name, waitingContainer("CrashLoopBackOff", 2))
+		pod.Spec.NodeName = "node-1"
+		pods = append(pods, pod)
+	}
+	pods[2].(*v1.Pod).Spec.NodeName = "node-2"
+	pending := testPod("web-4", waitingContainer("ContainerCreating", 0))
+	result := validate(t, testOptions(), append(pods, testDeployment(false), node, healthy, pending)...)
+
+	want := []NodeProblem{{Name: "node-1", Conditions: []string{"MemoryPressure: kubelet has insufficient memory available"}, Pods: []string{"web-1", "web-2"}}}
+	if !reflect.DeepEqual(result.Nodes, want) {
+		t.Errorf("Nodes = %+v, want %+v", result.Nodes, want)
+	}
+	for _, pod := range result.Pods {
+		unhealthy := len(pod.Diagnosis) == 1 && pod.Diagnosis[0].Reason == "NodeUnhealthy" && pod.Diagnosis[0].Category == CategoryNode
+		if unhealthy != (pod.Name == "web-1" || pod.Name == "web-2") {
+			t.Errorf("pod %v diagnosis = %+v", pod.Name, pod.Diagnosis)
+		}
+	}
+}
+
+func TestValidatePendingPersistentVolumeClaim(t *testing.T) {
+	class := "fast"
+	claim := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-web-0", Namespace: testNamespace},
+		Spec: v1.PersistentVolumeClaimSpec{
+			StorageClassName: &class,
+			AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources:        v1.VolumeResourceRequirements{Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")}},
+		},
+		Status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending},
+	}
+	volume := func(name, size string) *v1.PersistentVolume {
+		return &v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1.PersistentVolumeSpec{
+				StorageClassName: class,
+				AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+				Capacity:         v1.ResourceList{v1.ResourceStorage: resource.MustParse(size)},
+			},
+			Status: v1.PersistentVolumeStatus{Phase: v1.VolumeAvailable},
+		}
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: testNamespace, Labels: map[string]string{"app": "web"}},
+		Spec: v1.PodSpec{Volumes: []v1.Volume{
+			{Name: "data", VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "data-web-0"}}},
+			{Name: "cache", VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "cache"}}},
+		}},
+		Status: v1.PodStatus{Phase: v1.PodPending},
+	}
+	result := validate(t, testOptions(), testDeployment(false), pod, claim, volume("pv-small", "5Gi"), volume("pv-large", "20Gi"))
+
+	if len(result.Pods) != 1 {
+		t.Fatalf("expected one pod, got %+v", result.Pods)
+	}
+	var messages []string
+	for _, diagnosis := range result.Pods[0].Diagnosis {
+		if diagnosis.Reason == "UnboundPersistentVolumeClaim" {
+			messages = append(messages, diagnosis.Message)
+		}
+	}
+	want := []string{
+		`PersistentVolumeClaim data-web-0 of volume data is Pending (StorageClass "fast", requests 10Gi ReadWriteOnce), StorageClass fast does not exist, Available PersistentVolumes that match it: pv-large, check kubectl describe pvc data-web-0 -n apps for the provisioning events`,
+		"Volume cache uses PersistentVolumeClaim cache which does not exist in namespace apps, please add it",
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("claim diagnosis = %q, want %q", messages, want)
+	}
+}
+
+func TestValidateDeploymentConfig(t *testing.T) {
+	deploymentConfig := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.openshift.io/v1",
+		"kind":       "DeploymentConfig",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": testNamespace},
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"selector": map[string]interface{}{"app": "web"},
+		},
+		"status": map[string]interface{}{
+			"latestVersion":   int64(3),
+			"readyReplicas":   int64(1),
+			"updatedReplicas": int64(1),
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "False"},
+				map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded", "message": "replication controller \"web-3\" has failed progressing"},
+			},
+		},
+	}}
+	old := testPod("web-2-abcde", waitingContainer("CrashLoopBackOff", 7))
+	old.Labels["deployment"] = "web-2"
+	latest := testPod("web-3-fghij", waitingContainer("ImagePullBackOff", 0))
+	latest.Labels["deployment"] = "web-3"
+
+	opts := testOptions()
+	opts.Kind = "deploymentconfig"
+	opts.Timeout = time.Minute
+	v := New(fake.NewSimpleClientset(old, latest), opts)
+	v.Dynamic = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{deploymentConfigs: "DeploymentConfigList"}, deploymentConfig)
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if result.Ready || result.Kind != "DeploymentConfig" {
+		t.Fatalf("expected a failed DeploymentConfig, got ready %v kind %v", result.Ready, result.Kind)
+	}
+	want := []string{
+		"DeploymentConfig web: 1/2 replicas ready, 1 updated, 0 available (version 3)",
+		`Progressing: replication controller "web-3" has failed progressing`,
+		"DeploymentConfig web exceeded its progress deadline",
+	}
+	if !reflect.DeepEqual(result.Status, want) {
+		t.Errorf("Status = %q, want %q", result.Status, want)
+	}
+	if len(result.Pods) != 1 || result.Pods[0].Name != "web-3-fghij" {
+		t.Errorf("expected only the pod of the latest version to be diagnosed, got %+v", result.Pods)
+	}
+}
+
+func TestDeploymentConfigStatus(t *testing.T) {
+	deploymentConfig := func(spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps.openshift.io/v1",
+			"kind":       "DeploymentConfig",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": testNamespace},
+			"spec":       spec,
+		}}
+	}
+
+	if _, err := deploymentConfigStatus(deploymentConfig(map[string]interface{}{"replicas": int64(2)})); err == nil || !strings.Contains(err.Error(), "has no selector") {
+		t.Errorf("expected an error for a DeploymentConfig without a selector, got %v", err)
+	}
+
+	w, err := deploymentConfigStatus(deploymentConfig(map[string]interface{}{"replicas": int64(0), "selector": map[string]interface{}{"app": "web"}}))
+	if err != nil {
+		t.Fatalf("deploymentConfigStatus() error = %v", err)
+	}
+	if !w.ready || !strings.Contains(strings.Join(w.status, "\n"), "web is scaled to 0 replicas") {
+		t.Errorf("expected a DeploymentConfig scaled to 0 replicas to be ready, got ready %v, %q", w.ready, w.status)
+	}
+}
+
+func TestValidateCustomResource(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+	rollout := func(ready string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Rollout",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": testNamespace},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": ready}},
+			},
+		}}
+	}
+	opts := testOptions()
+	opts.Resource = &CustomResource{GVR: gvr, ReadyPath: `{.status.conditions[?(@.type=="Ready")].status}`, ReadyValue: "True"}
+
+	for _, ready := range []string{"True", "False"} {
+		v := New(fake.NewSimpleClientset(testPod("web-1", waitingContainer("CrashLoopBackOff", 1))), opts)
+		v.Dynamic = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "RolloutList"}, rollout(ready))
+		result, err := v.Validate(context.Background())
+		if err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+		if result.Ready != (ready == "True") || result.Kind != "rollouts" {
+			t.Errorf("Ready condition %v: got ready %v kind %v", ready, result.Ready, result.Kind)
+		}
+		if ready == "False" && len(result.Pods) != 1 {
+			t.Errorf("expected the pods matching spec.selector to be diagnosed, got %+v", result.Pods)
+		}
+	}
+}
+
+func TestCheckAccess(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		// A namespaced Role does not grant cluster scoped resources.
+		review.Status.Allowed = attributes.Namespace == testNamespace && attributes.Resource != "secrets" && attributes.Subresource != "log"
+		return true, review, nil
+	})
+
+	denied, err := New(client, testOptions()).CheckAccess(context.Background())
+	if err != nil {
+		t.Fatalf("CheckAccess() error = %v", err)
+	}
+	var got []string
+	for _, permission := range denied {
+		got = append(got, fmt.Sprintf("%v %v", permission, permission.Required))
+	}
+	if want := []string{"get pods/log false", "get secrets false", "list persistentvolumes false", "get storageclasses.storage.k8s.io false", "get nodes false"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("denied = %q, want %q", got, want)
+	}
+
+	opts := testOptions()
+	opts.Namespace = "other"
+	denied, _ = New(client, opts).CheckAccess(context.Background())
+	if len(denied) == 0 || denied[0].String() != "get deployments.apps" || !denied[0].Required {
+		t.Errorf("expected get deployments to be denied first and required, got %+v", denied)
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts func(*Options)
+		want []string
+	}{
+		{
+			name: "deployment",
+			opts: func(*Options) {},
+			want: []string{"get deployments.apps true", "list replicasets.apps false", "list pods true", "get pods false", "get pods/log false", "list events false", "get secrets false", "get persistentvolumeclaims false", "list persistentvolumes false", "get storageclasses.storage.k8s.io false", "get nodes false"},
+		},
+		{
+			name: "selector with service probe",
+			opts: func(opts *Options) { opts.Selector, opts.ServiceProbe = "app=web", &ServiceProbe{} },
+			want: []string{"list pods true", "get pods false", "get pods/log false", "list events false", "get secrets false", "get persistentvolumeclaims false", "list persistentvolumes false", "get storageclasses.storage.k8s.io false", "get nodes false", "list services false", "get services/proxy false"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testOptions()
+			tt.opts(&opts)
+			var got []string
+			for _, permission := range Permissions(opts) {
+				got = append(got, fmt.Sprintf("%v %v", permission, permission.Required))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Permissions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPermissionsCoverClientCalls checks that Permissions lists every call a
+// validation makes with the options that turn them on.
+func TestPermissionsCoverClientCalls(t *testing.T) {
+	class := "fast"
+	claim := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: testNamespace},
+		Spec:       v1.PersistentVolumeClaimSpec{StorageClassName: &class},
+		Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending},
+	}
+	crashing := testPod("web-1", waitingContainer("CrashLoopBackOff", 1))
+	crashing.Spec.NodeName = "node-1"
+	pulling := testPod("web-2", waitingContainer("ImagePullBackOff", 0))
+	pending := testPod("web-3", waitingContainer("ContainerCreating", 0))
+	pending.Spec.Volumes = []v1.Volume{{Name: "data", VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}}}}
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+		Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+	}
+	tests := []struct {
+		name    string
+		opts    func(*Options)
+		objects []runtime.Object
+	}{
+		{
+			name:    "deployment watched with events",
+			opts:    func(opts *Options) { opts.Watch, opts.FollowEvents = true, true },
+			objects: []runtime.Object{testDeployment(false)},
+		},
+		{
+			name:    "service watched",
+			opts:    func(opts *Options) { opts.Kind, opts.Watch = "service", true },
+			objects: []runtime.Object{service},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(append(tt.objects, crashing, pulling, pending, claim)...)
+			// Without EndpointSlices the Service falls back to its Endpoints.
+			client.PrependReactor("list", "endpointslices", func(k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "discovery.k8s.io", Resource: "endpointslices"}, "", fmt.Errorf("denied"))
+			})
+			watcher := watch.NewFake()
+			client.PrependWatchReactor("events", k8stesting.DefaultWatchReactor(watcher, nil))
+			go watcher.Add(&v1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Name: "web-1.1", Namespace: testNamespace},
+				InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "web-1", Namespace: testNamespace},
+				Type:           v1.EventTypeWarning,
+				Reason:         "BackOff",
+			})
+			opts := testOptions()
+			opts.Timeout, opts.Interval = 50*time.Millisecond, 10*time.Millisecond
+			tt.opts(&opts)
+			v := New(client, opts)
+			v.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+			if _, err := v.Validate(context.Background()); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if _, err := v.CollectArtifacts(context.Background(), "web-1"); err != nil {
+				t.Fatalf("CollectArtifacts() error = %v", err)
+			}
+
+			permitted := map[string]bool{}
+			for _, permission := range Permissions(opts) {
+				permitted[permission.String()] = true
+			}
+			for _, action := range client.Actions() {
+				resource := action.GetResource()
+				call := Permission{Verb: action.GetVerb(), Group: resource.Group, Resource: resource.Resource, Subresource: action.GetSubresource()}
+				if !permitted[call.String()] {
+					t.Errorf("the validation calls %v which Permissions() does not list", call)
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshotChanges(t *testing.T) {
+	previous := &Result{
+		Namespace: testNamespace, Kind: "Deployment", Name: "web",
+		Summary: Summary{Total: 2, Ready: 2},
+		Images: []ImageVersion{
+			{Container: "app", Image: "web:1.0", ImageID: "web@sha256:aaa", Pods: []string{"web-1", "web-2"}},
+			{Container: "proxy", Image: "proxy:1.0", ImageID: "proxy@sha256:ppp", Pods: []string{"web-1", "web-2"}},
+		},
+	}
+	snapshot := NewSnapshot(previous, time.Now())
+	if got := snapshot.Changes(previous); len(got) != 0 {
+		t.Errorf("Changes() of the same result = %q, want none", got)
+	}
+
+	current := &Result{
+		Namespace: testNamespace, Kind: "Deployment", Name: "web",
+		Summary: Summary{Total: 3, Ready: 2, Failing: 1},
+		Images: []ImageVersion{
+			{Container: "app", Image: "web:1.1", ImageID: "web@sha256:bbb", Pods: []string{"web-3"}},
+			{Container: "proxy", Image: "proxy:1.0", ImageID: "proxy@sha256:ppp", Pods: []string{"web-3"}},
+		},
+		Pods: []PodResult{{Name: "web-3", Containers: []ContainerResult{{Name: "app"}, {Name: "proxy", Ready: true}}}},
+	}
+	want := []string{
+		"Replicas changed from 2 to 3",
+		"Container app changed its image from web:1.0 (sha256:aaa) to web:1.1 (sha256:bbb)",
+		"Container app is failing now in web-3",
+	}
+	if got := snapshot.Changes(current); !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes() = %q, want %q", got, want)
+	}
+}
+
+func TestCollectArtifacts(t *testing.T) {
+	pod := testPod("web-1", waitingContainer("CrashLoopBackOff", 2))
+	event := &v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web-1.1", Namespace: testNamespace},
+		InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "web-1"},
+		Reason:         "BackOff",
+	}
+	v := New(fake.NewSimpleClientset(pod, event), testOptions())
+	artifacts, err := v.CollectArtifacts(context.Background(), "web-1")
+	if err != nil {
+		t.Fatalf("CollectArtifacts() error = %v", err)
+	}
+	var names []string
+	for _, file := range artifacts.Files {
+		names = append(names, file.Name)
+	}
+	want := []string{"pod.yaml", "events.yaml", "logs/app.log", "logs/app.previous.log"}
+	if !reflect.DeepEqual(names, want) || len(artifacts.Errors) != 0 {
+		t.Fatalf("CollectArtifacts() files = %q, errors = %q, want %q", names, artifacts.Errors, want)
+	}
+	if got := string(artifacts.Files[1].Data); !strings.Contains(got, "reason: BackOff") {
+		t.Errorf("expected events.yaml to contain the event, got %q", got)
+	}
+}
+
+func TestValidateReportsPodsInNameOrder(t *testing.T) {
+	objects := []runtime.Object{testDeployment(false)}
+	for _, name := range []string{"web-c", "web-a", "web-d", "web-b"} {
+		objects = append(objects, testPod(name, waitingContainer("CrashLoopBackOff", 1)))
+	}
+	opts := testOptions()
+	opts.MaxConcurrentPods = 4
+	for run := 0; run < 5; run++ {
+		result := validate(t, opts, objects...)
+		var names []string
+		for _, pod := range result.Pods {
+			names = append(names, pod.Name)
+		}
+		if want := []string{"web-a", "web-b", "web-c", "web-d"}; !reflect.DeepEqual(names, want) {
+			t.Fatalf("run %v: pods = %q, want %q", run, names, want)
+		}
+	}
+}
+
+func TestValidateWaitsForObservedGeneration(t *testing.T) {
+	deployment := testDeployment(true)
+	deployment.Generation, deployment.Status.ObservedGeneration = 3, 2
+	result := validate(t, testOptions(), deployment, testPod("web-1", waitingContainer("CrashLoopBackOff", 1)))
+	if result.Ready {
+		t.Errorf("expected a deployment whose spec was not observed yet not to be ready")
+	}
+	status := strings.Join(result.Status, "\n")
+	if want := "Deployment web: waiting for controller to observe new spec (generation 3, observed 2)"; !strings.Contains(status, want) {
+		t.Errorf("expected the status to contain %q, got %q", want, status)
+	}
+	if len(result.Pods) != 0 || result.Summary.Failing != 1 {
+		t.Errorf("expected the pods to be counted but not diagnosed, got %v diagnosed, summary %v", len(result.Pods), result.Summary)
+	}
+}
+
+func TestValidateOnlySelectedContainers(t *testing.T) {
+	pod := testPod("web-1", waitingContainer("CrashLoopBackOff", 1))
+	proxy := waitingContainer("ImagePullBackOff", 0)
+	proxy.Name = "proxy"
+	pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, proxy)
+	pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: "proxy", Image: "registry.example.com/proxy:1.0"})
+
+	opts := testOptions()
+	opts.Containers = []string{"app"}
+	result := validate(t, opts, testDeployment(false), pod)
+	if len(result.Pods) != 1 || len(result.Pods[0].Containers) != 1 || result.Pods[0].Containers[0].Name != "app" {
+		t.Fatalf("expected only container app to be diagnosed, got %+v", result.Pods)
+	}
+
+	// A failing container that is left out still keeps the pod from being ready.
+	pod.Status.ContainerStatuses[0] = v1.ContainerStatus{Name: "app", Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}
+	result = validate(t, opts, testDeployment(false), pod)
+	want := []Diagnosis{{Reason: "ExcludedContainerNotReady", Category: CategoryUnknown, Message: "Containers proxy are not ready and keep the pod from being ready, they are not diagnosed as they are not among the selected containers"}}
+	if len(result.Pods) != 1 || !reflect.DeepEqual(withoutHints(result.Pods[0].Diagnosis), want) {
+		t.Errorf("expected the excluded container to be reported, got %+v", result.Pods)
+	}
+
+	opts.Containers = []string{"app", "sidecar"}
+	result = validate(t, opts, testDeployment(false), pod)
+	if status := strings.Join(result.Status, "\n"); !strings.Contains(status, "No pod has a container named sidecar") {
+		t.Errorf("expected the status to report the unknown container name, got %q", status)
+	}
+}
+
+func TestValidateFailFast(t *testing.T) {
+	objects := []runtime.Object{testDeployment(false)}
+	for _, name := range []string{"web-1", "web-2", "web-3"} {
+		objects = append(objects, testPod(name, waitingContainer("CrashLoopBackOff", 1)))
+	}
+	opts := testOptions()
+	opts.FailFast = true
+	result := validate(t, opts, objects...)
+	if len(result.Pods) != 1 || result.Pods[0].Ready {
+		t.Fatalf("expected only one failing pod to be diagnosed, got %+v", result.Pods)
+	}
+	if status := strings.Join(result.Status, "\n"); !strings.Contains(status, "2 other pods are not reported") {
+		t.Errorf("expected the status to report the pods that were not diagnosed, got %q", status)
+	}
+	if result.Summary.Failing != 3 {
+		t.Errorf("expected the summary to count every failing pod, got %v", result.Summary)
+	}
+}
+
+func TestValidateFailFastReportsFirstFailingPod(t *testing.T) {
+	ready := testPod("web-1", v1.ContainerStatus{Name: "app", Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}})
+	ready.Status.Phase = v1.PodRunning
+	ready.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	objects := []runtime.Object{testDeployment(false), ready}
+	for _, name := range []string{"web-2", "web-3", "web-4", "web-5"} {
+		objects = append(objects, testPod(name, waitingContainer("CrashLoopBackOff", 1)))
+	}
+	opts := testOptions()
+	opts.FailFast = true
+	// The pods are diagnosed concurrently, every run must report the same one.
+	for run := 0; run < 20; run++ {
+		result := validate(t, opts, objects...)
+		if len(result.Pods) != 1 || result.Pods[0].Name != "web-2" {
+			t.Fatalf("run %v: expected the first failing pod web-2 to be reported, got %+v", run, result.Pods)
+		}
+	}
+}
+
+func TestValidateEvicted(t *testing.T) {
+	pod := testPod("web-1", v1.ContainerStatus{
+		Name:  "app",
+		State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "ContainerStatusUnknown", ExitCode: 137}},
+	})
+	pod.Spec.NodeName = "node-1"
+	pod.Status.Phase, pod.Status.Reason = v1.PodFailed, "Evicted"
+	pod.Status.Message = "The node was low on resource: memory. Threshold quantity: 100Mi, available: 60Mi. "
+	result := validate(t, testOptions(), testDeployment(false), pod)
+
+	if len(result.Pods) != 1 || len(result.Pods[0].Diagnosis) != 1 {
+		t.Fatalf("expected one pod diagnosis, got %+v", result.Pods)
+	}
+	diagnosis := result.Pods[0].Diagnosis[0]
+	if diagnosis.Reason != "Evicted" || !strings.Contains(diagnosis.Message, "evicted from node node-1 because it was low on memory: The node was low on resource: memory. Threshold quantity: 100Mi, available: 60Mi, check") {
+		t.Errorf("unexpected diagnosis %+v", diagnosis)
+	}
+}
+
+func TestValidateTimings(t *testing.T) {
+	v := New(fake.NewSimpleClientset(testDeployment(false), testPod("web-1", waitingContainer("CrashLoopBackOff", 1))), testOptions())
+	if _, err := v.Validate(context.Background()); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	counts := map[string]int{}
+	for _, timing := range v.Timings() {
+		counts[timing.Phase] = timing.Count
+	}
+	for _, phase := range []string{"get deployment", "list pods", "list events", "fetch logs of pod web-1"} {
+		if counts[phase] == 0 {
+			t.Errorf("expected a timing for %q, got %+v", phase, v.Timings())
+		}
+	}
+}
@@ -0,0 +1,595 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	Appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/pager"
+)
+
+// revisionAnnotation records the rollout revision of a Deployment's
+// ReplicaSets.
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// kindNames maps the accepted Options.Kind values to their display names.
+var kindNames = map[string]string{
+	"cronjob":          "CronJob",
+	"daemonset":        "DaemonSet",
+	"deployment":       "Deployment",
+	"deploymentconfig": "DeploymentConfig",
+	"job":              "Job",
+	"replicaset":       "ReplicaSet",
+	"service":          "Service",
+	"statefulset":      "StatefulSet",
+}
+
+// kindName is the display name of the kind being validated.
+func (v *Validator) kindName() string {
+	if v.options.Resource != nil {
+		return v.options.Resource.GVR.Resource
+	}
+	return kindNames[v.options.Kind]
+}
+
+// resourceName is the kind being validated as it is given on the command
+// line, for messages.
+func (v *Validator) resourceName() string {
+	if v.options.Resource != nil {
+		return v.options.Resource.GVR.Resource
+	}
+	return v.options.Kind
+}
+
+// SupportedKinds returns the accepted Options.Kind values in sorted order.
+func SupportedKinds() []string {
+	kinds := make([]string, 0, len(kindNames))
+	for kind := range kindNames {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// workload is the rollout state of the object being validated and the
+// selector of the pods it manages. failed means the workload reached a
+// terminal failure and there is no point in waiting any longer. If owner is
+// set, only the pods it controls are diagnosed.
+type workload struct {
+	selector        string
+	owner           types.UID
+	ready           bool
+	failed          bool
+	paused          bool
+	stale           bool
+	status          []string
+	resourceVersion string
+}
+
+func (w *workload) report(format string, args ...interface{}) {
+	w.status = append(w.status, fmt.Sprintf(format, args...))
+}
+
+// scaledToZero makes a workload scaled to 0 replicas ready, it has no pods
+// because none are wanted.
+func (w *workload) scaledToZero(name string) {
+	w.report("%v is scaled to 0 replicas, no pods are expected", name)
+	w.ready = true
+}
+
+func (v *Validator) getWorkload(ctx context.Context) (workload, error) {
+	namespace, name := v.options.Namespace, v.options.Name
+	defer v.track("get "+v.resourceName(), time.Now())
+	var object runtime.Object
+	err := v.retry(ctx, "getting "+v.resourceName(), func() (err error) {
+		if resource := v.options.Resource; resource != nil {
+			if v.Dynamic == nil {
+				return fmt.Errorf("a dynamic client is required for %v", resource.GVR.Resource)
+			}
+			object, err = v.Dynamic.Resource(resource.GVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+			return err
+		}
+		switch v.options.Kind {
+		case "statefulset":
+			object, err = v.client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		case "daemonset":
+			object, err = v.client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		case "replicaset":
+			object, err = v.client.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		case "deploymentconfig":
+			if v.Dynamic == nil {
+				return fmt.Errorf("a dynamic client is required for %v", v.options.Kind)
+			}
+			object, err = v.Dynamic.Resource(deploymentConfigs).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		case "job":
+			object, err = v.client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		case "cronjob":
+			object, err = v.client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		case "service":
+			object, err = v.client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		default:
+			object, err = v.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return workload{}, err
+	}
+	return v.workloadStatus(ctx, object)
+}
+
+func (v *Validator) watchWorkload(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+	namespace := v.options.Namespace
+	if resource := v.options.Resource; resource != nil {
+		if v.Dynamic == nil {
+			return nil, fmt.Errorf("a dynamic client is required for %v", resource.GVR.Resource)
+		}
+		return v.Dynamic.Resource(resource.GVR).Namespace(namespace).Watch(ctx, options)
+	}
+	switch v.options.Kind {
+	case "statefulset":
+		return v.client.AppsV1().StatefulSets(namespace).Watch(ctx, options)
+	case "daemonset":
+		return v.client.AppsV1().DaemonSets(namespace).Watch(ctx, options)
+	case "replicaset":
+		return v.client.AppsV1().ReplicaSets(namespace).Watch(ctx, options)
+	case "deploymentconfig":
+		if v.Dynamic == nil {
+			return nil, fmt.Errorf("a dynamic client is required for %v", v.options.Kind)
+		}
+		return v.Dynamic.Resource(deploymentConfigs).Namespace(namespace).Watch(ctx, options)
+	case "job":
+		return v.client.BatchV1().Jobs(namespace).Watch(ctx, options)
+	case "cronjob":
+		return v.client.BatchV1().CronJobs(namespace).Watch(ctx, options)
+	case "service":
+		// The endpoints change as the pods become ready, not the Service.
+		return v.client.CoreV1().Endpoints(namespace).Watch(ctx, options)
+	default:
+		return v.client.AppsV1().Deployments(namespace).Watch(ctx, options)
+	}
+}
+
+func (v *Validator) workloadStatus(ctx context.Context, object runtime.Object) (workload, error) {
+	var w workload
+	var err error
+	switch object := object.(type) {
+	case *Appsv1.StatefulSet:
+		w = statefulSetStatus(object)
+	case *Appsv1.DaemonSet:
+		w, err = v.daemonSetStatus(ctx, object)
+	case *Appsv1.ReplicaSet:
+		w = replicaSetStatus(object)
+	case *batchv1.Job:
+		w = jobStatus(object)
+	case *batchv1.CronJob:
+		w, err = v.cronJobStatus(ctx, object)
+	case *v1.Service:
+		w, err = v.serviceStatus(ctx, object)
+	case *v1.Endpoints:
+		// Watched in place of the Service, whose status is checked again.
+		return v.getWorkload(ctx)
+	case *Appsv1.Deployment:
+		w = deploymentStatus(object, v.options.Condition)
+		current := v.currentReplicaSet(ctx, object, &w)
+		if v.options.Revision > 0 {
+			checkRevision(object, current, v.options.Revision, &w)
+		}
+		checkPaused(object, &w)
+	case *unstructured.Unstructured:
+		if v.options.Resource != nil {
+			w, err = customStatus(v.options.Resource, object)
+		} else {
+			w, err = deploymentConfigStatus(object)
+		}
+	default:
+		return workload{}, fmt.Errorf("unexpected object %T", object)
+	}
+	if accessor, accessorErr := meta.Accessor(object); accessorErr == nil {
+		w.resourceVersion = accessor.GetResourceVersion()
+		if observed, ok := observedGeneration(object); ok && observed < accessor.GetGeneration() {
+			w.report("%v %v: waiting for controller to observe new spec (generation %v, observed %v)", v.kindName(), accessor.GetName(), accessor.GetGeneration(), observed)
+			// The status is of the previous spec, neither its readiness
+			// nor its failures are current.
+			w.ready, w.failed, w.paused, w.stale = false, false, false, true
+		}
+	}
+	return w, err
+}
+
+// observedGeneration is the generation of the spec the controller of the
+// workload last acted on, false for kinds that do not report one.
+func observedGeneration(object runtime.Object) (int64, bool) {
+	switch object := object.(type) {
+	case *Appsv1.Deployment:
+		return object.Status.ObservedGeneration, true
+	case *Appsv1.StatefulSet:
+		return object.Status.ObservedGeneration, true
+	case *Appsv1.DaemonSet:
+		return object.Status.ObservedGeneration, true
+	case *Appsv1.ReplicaSet:
+		return object.Status.ObservedGeneration, true
+	case *unstructured.Unstructured:
+		observed, found, err := unstructured.NestedInt64(object.Object, "status", "observedGeneration")
+		return observed, found && err == nil
+	}
+	return 0, false
+}
+
+// deploymentStatus reports the replica counts of a Deployment and the message
+// of its Progressing condition, e.g. "ReplicaSet "web-5d4f" is progressing.".
+// It is ready once it has the wanted condition or, if wanted is not set, once
+// its rollout is complete. A Deployment that exceeded its progress deadline
+// has failed.
+func deploymentStatus(deployment *Appsv1.Deployment, wanted Condition) workload {
+	w := workload{selector: metav1.FormatLabelSelector(deployment.Spec.Selector)}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	w.report("Deployment %v: %v/%v replicas ready, %v updated, %v available", deployment.Name, status.ReadyReplicas, replicas, status.UpdatedReplicas, status.AvailableReplicas)
+	if replicas == 0 {
+		w.scaledToZero(deployment.Name)
+		return w
+	}
+	var present []string
+	for _, condition := range status.Conditions {
+		present = append(present, fmt.Sprintf("%v=%v", condition.Type, condition.Status))
+		if condition.Type == Appsv1.DeploymentProgressing && condition.Message != "" {
+			w.report("Progressing: %v", condition.Message)
+		}
+		if condition.Type == Appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			w.report("Deployment %v exceeded its progress deadline", deployment.Name)
+			w.failed = true
+		}
+		if string(condition.Type) == wanted.Type && string(condition.Status) == wanted.Status {
+			w.ready = true
+		}
+	}
+	switch {
+	case wanted == (Condition{}):
+		rolloutStatus(deployment, replicas, &w)
+	case w.ready:
+		w.report("Deployment %v has condition %v", deployment.Name, wanted)
+	case len(present) == 0:
+		w.report("Deployment %v has no conditions yet, waiting for %v", deployment.Name, wanted)
+	default:
+		w.report("Deployment %v does not have condition %v, it has %v", deployment.Name, wanted, strings.Join(present, ", "))
+	}
+	return w
+}
+
+// rolloutStatus makes the Deployment ready once its rollout is complete, as
+// kubectl rollout status decides it: every replica was updated, no old
+// replica is left and every updated replica is available. It reports the
+// progress in the words of kubectl. That the controller observed the latest
+// spec is checked for every kind by workloadStatus.
+func rolloutStatus(deployment *Appsv1.Deployment, replicas int32, w *workload) {
+	status := deployment.Status
+	switch {
+	case status.UpdatedReplicas < replicas:
+		w.report("Waiting for deployment %q rollout to finish: %v out of %v new replicas have been updated...", deployment.Name, status.UpdatedReplicas, replicas)
+	case status.Replicas > status.UpdatedReplicas:
+		w.report("Waiting for deployment %q rollout to finish: %v old replicas are pending termination...", deployment.Name, status.Replicas-status.UpdatedReplicas)
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		w.report("Waiting for deployment %q rollout to finish: %v of %v updated replicas are available...", deployment.Name, status.AvailableReplicas, status.UpdatedReplicas)
+	default:
+		w.report("deployment %q successfully rolled out", deployment.Name)
+		w.ready = true
+	}
+}
+
+// currentReplicaSet sets the owner of the workload to the newest ReplicaSet of
+// the Deployment, by the deployment.kubernetes.io/revision annotation, so that
+// pods of old ReplicaSets being scaled down during a rollout are not diagnosed
+// as failures. The pods are not filtered if the ReplicaSets cannot be listed.
+func (v *Validator) currentReplicaSet(ctx context.Context, deployment *Appsv1.Deployment, w *workload) *Appsv1.ReplicaSet {
+	var replicaSets *Appsv1.ReplicaSetList
+	err := v.retry(ctx, "getting replica sets", func() (err error) {
+		replicaSets, err = v.client.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{LabelSelector: w.selector})
+		return err
+	})
+	if err != nil {
+		v.logger().Warn("Could not find the current ReplicaSet, diagnosing the pods of every revision", "deployment", deployment.Name, "error", err)
+		return nil
+	}
+	var current *Appsv1.ReplicaSet
+	revision := int64(-1)
+	for i, replicaSet := range replicaSets.Items {
+		if !metav1.IsControlledBy(&replicaSets.Items[i], deployment) {
+			continue
+		}
+		rev, err := strconv.ParseInt(replicaSet.Annotations[revisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		if rev > revision {
+			current, revision = &replicaSets.Items[i], rev
+		}
+	}
+	if current == nil {
+		return nil
+	}
+	w.owner = current.UID
+	w.report("Current ReplicaSet %v (revision %v): %v/%v replicas ready", current.Name, revision, current.Status.ReadyReplicas, current.Status.Replicas)
+	return current
+}
+
+// checkRevision only lets the Deployment be ready once it and its newest
+// ReplicaSet, if known, are at revision. Revisions only grow, a rollback
+// included, so a newer revision means another release replaced the expected
+// one and there is no point in waiting.
+func checkRevision(deployment *Appsv1.Deployment, current *Appsv1.ReplicaSet, revision int64, w *workload) {
+	active, err := strconv.ParseInt(deployment.Annotations[revisionAnnotation], 10, 64)
+	if err != nil {
+		w.report("Deployment %v has no %v annotation yet, waiting for revision %v", deployment.Name, revisionAnnotation, revision)
+		w.ready = false
+		return
+	}
+	switch {
+	case active < revision:
+		w.report("Deployment %v is at revision %v, waiting for revision %v", deployment.Name, active, revision)
+		w.ready = false
+		return
+	case active > revision:
+		w.report("Revision mismatch: Deployment %v is at revision %v instead of %v, a newer rollout or a rollback replaced it", deployment.Name, active, revision)
+		w.ready, w.failed = false, true
+		return
+	}
+	if current != nil && current.Annotations[revisionAnnotation] != strconv.FormatInt(revision, 10) {
+		w.report("Revision mismatch: the newest ReplicaSet %v is at revision %v instead of %v", current.Name, current.Annotations[revisionAnnotation], revision)
+		w.ready = false
+		return
+	}
+	w.report("Deployment %v is at revision %v", deployment.Name, revision)
+}
+
+// checkPaused stops waiting for a paused Deployment that is not ready, the
+// controller does not roll it out until it is resumed.
+func checkPaused(deployment *Appsv1.Deployment, w *workload) {
+	if !deployment.Spec.Paused {
+		return
+	}
+	switch {
+	case w.ready:
+		w.report("Deployment %v is paused, changes to its pod template will not roll out until it is resumed", deployment.Name)
+	case !w.failed:
+		w.report("Deployment %v is paused and will not become ready until it is resumed with: kubectl rollout resume deployment/%v -n %v", deployment.Name, deployment.Name, deployment.Namespace)
+		w.paused, w.failed = true, true
+	}
+}
+
+// ownedPods returns the pods controlled by owner, or all pods if owner is
+// empty.
+func ownedPods(pods *v1.PodList, owner types.UID) *v1.PodList {
+	if owner == "" {
+		return pods
+	}
+	owned := &v1.PodList{ListMeta: pods.ListMeta}
+	for _, pod := range pods.Items {
+		if controller := metav1.GetControllerOf(&pod); controller != nil && controller.UID == owner {
+			owned.Items = append(owned.Items, pod)
+		}
+	}
+	return owned
+}
+
+// statefulSetStatus reports the replica counts of a StatefulSet. Pods are
+// rolled out one at a time, so it is only ready once every replica is ready
+// and running the update revision.
+func statefulSetStatus(statefulSet *Appsv1.StatefulSet) workload {
+	w := workload{selector: metav1.FormatLabelSelector(statefulSet.Spec.Selector)}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	status := statefulSet.Status
+	w.report("StatefulSet %v: %v/%v replicas ready, %v updated (revision %v)", statefulSet.Name, status.ReadyReplicas, replicas, status.UpdatedReplicas, status.UpdateRevision)
+	if replicas == 0 {
+		w.scaledToZero(statefulSet.Name)
+		return w
+	}
+	for _, condition := range status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			w.report("StatefulSet condition %v=%v: %v", condition.Type, condition.Status, condition.Message)
+		}
+	}
+
+	w.ready = status.ObservedGeneration >= statefulSet.Generation &&
+		status.ReadyReplicas >= replicas &&
+		(status.UpdateRevision == "" || status.UpdateRevision == status.CurrentRevision)
+	return w
+}
+
+// replicaSetStatus reports the replica counts of a ReplicaSet managed
+// directly or picked from the revisions of a Deployment. It is ready once it
+// has observed its latest spec and every replica is ready.
+func replicaSetStatus(replicaSet *Appsv1.ReplicaSet) workload {
+	w := workload{selector: metav1.FormatLabelSelector(replicaSet.Spec.Selector)}
+	replicas := int32(1)
+	if replicaSet.Spec.Replicas != nil {
+		replicas = *replicaSet.Spec.Replicas
+	}
+	status := replicaSet.Status
+	w.report("ReplicaSet %v: %v/%v replicas ready, %v available", replicaSet.Name, status.ReadyReplicas, replicas, status.AvailableReplicas)
+	if replicas == 0 {
+		w.scaledToZero(replicaSet.Name)
+		return w
+	}
+	for _, condition := range status.Conditions {
+		if condition.Type == Appsv1.ReplicaSetReplicaFailure && condition.Status == v1.ConditionTrue {
+			w.report("ReplicaFailure: %v", condition.Message)
+		}
+	}
+
+	w.ready = status.ObservedGeneration >= replicaSet.Generation && status.ReadyReplicas >= replicas
+	return w
+}
+
+// daemonSetStatus reports the scheduling counts of a DaemonSet and the nodes
+// whose daemon pod is not ready. A DaemonSet is ready once every node it
+// should run on has an updated, ready pod.
+func (v *Validator) daemonSetStatus(ctx context.Context, daemonSet *Appsv1.DaemonSet) (workload, error) {
+	w := workload{selector: metav1.FormatLabelSelector(daemonSet.Spec.Selector)}
+	status := daemonSet.Status
+	w.report("DaemonSet %v: desired %v, current %v, ready %v, updated %v, available %v", daemonSet.Name, status.DesiredNumberScheduled, status.CurrentNumberScheduled, status.NumberReady, status.UpdatedNumberScheduled, status.NumberAvailable)
+
+	w.ready = status.ObservedGeneration >= daemonSet.Generation &&
+		status.NumberReady == status.DesiredNumberScheduled &&
+		status.UpdatedNumberScheduled == status.DesiredNumberScheduled
+	if w.ready {
+		return w, nil
+	}
+
+	if missing := status.DesiredNumberScheduled - status.CurrentNumberScheduled; missing > 0 {
+		w.report("%v nodes have no daemon pod scheduled", missing)
+	}
+	if status.NumberMisscheduled > 0 {
+		w.report("%v nodes are running a daemon pod they should not run", status.NumberMisscheduled)
+	}
+	pods, err := v.listPods(ctx, w.selector)
+	if err != nil {
+		return workload{}, err
+	}
+	var nodes []string
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" && pod.Status.Phase != v1.PodSucceeded && !podReady(pod) {
+			nodes = append(nodes, fmt.Sprintf("%v (pod %v)", pod.Spec.NodeName, pod.Name))
+		}
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		w.report("Node %v has no healthy daemon pod", node)
+	}
+	return w, nil
+}
+
+// jobStatus reports the pod counts and retries of a Job and whether it
+// completed or failed.
+func jobStatus(job *batchv1.Job) workload {
+	w := workload{selector: metav1.FormatLabelSelector(job.Spec.Selector)}
+	backoffLimit := int32(6)
+	if job.Spec.BackoffLimit != nil {
+		backoffLimit = *job.Spec.BackoffLimit
+	}
+	status := job.Status
+	w.report("Job %v: %v succeeded, %v failed, %v active, %v/%v retries used", job.Name, status.Succeeded, status.Failed, status.Active, status.Failed, backoffLimit)
+
+	for _, condition := range status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			w.ready = true
+		case batchv1.JobFailed:
+			w.report("Job failed: %v %v", condition.Reason, condition.Message)
+			w.failed = true
+		}
+	}
+	return w
+}
+
+// cronJobStatus reports the schedule of a CronJob and validates its most
+// recent Job, the one it created last, like a Job of -kind job: the CronJob is
+// ready once that run completed and has failed if it failed. Only the pods of
+// that Job are diagnosed.
+func (v *Validator) cronJobStatus(ctx context.Context, cronJob *batchv1.CronJob) (workload, error) {
+	var w workload
+	lastSchedule := "never"
+	if cronJob.Status.LastScheduleTime != nil {
+		lastSchedule = cronJob.Status.LastScheduleTime.UTC().Format(time.RFC3339)
+	}
+	w.report("CronJob %v: schedule %q, last scheduled %v, %v active", cronJob.Name, cronJob.Spec.Schedule, lastSchedule, len(cronJob.Status.Active))
+	if cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend {
+		w.report("CronJob %v is suspended, it does not schedule new runs until it is resumed", cronJob.Name)
+	}
+
+	pages := pager.New(func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+		var page *batchv1.JobList
+		err := v.retry(ctx, "listing jobs", func() (err error) {
+			page, err = v.client.BatchV1().Jobs(cronJob.Namespace).List(ctx, options)
+			return err
+		})
+		return page, err
+	})
+	// The Jobs carry the labels of the JobTemplate, the owner reference is
+	// still checked as other Jobs can have the same labels.
+	var options metav1.ListOptions
+	if jobLabels := cronJob.Spec.JobTemplate.Labels; len(jobLabels) > 0 {
+		options.LabelSelector = labels.SelectorFromSet(jobLabels).String()
+	}
+	var last *batchv1.Job
+	err := pages.EachListItem(ctx, options, func(object runtime.Object) error {
+		job := object.(*batchv1.Job)
+		if !metav1.IsControlledBy(job, cronJob) {
+			return nil
+		}
+		if last == nil || last.CreationTimestamp.Before(&job.CreationTimestamp) {
+			last = job
+		}
+		return nil
+	})
+	if err != nil {
+		return workload{}, fmt.Errorf("error listing the jobs of cronjob %v: %w", cronJob.Name, err)
+	}
+	if last == nil {
+		// No pod is controlled by the CronJob itself, so none is diagnosed.
+		w.owner = cronJob.UID
+		w.selector = labels.SelectorFromSet(cronJob.Spec.JobTemplate.Spec.Template.Labels).String()
+		w.report("CronJob %v has no Job yet, waiting for its first run", cronJob.Name)
+		return w, nil
+	}
+
+	job := jobStatus(last)
+	job.status = append(w.status, job.status...)
+	job.owner = last.UID
+	switch {
+	case job.ready:
+		job.report("The last run %v of CronJob %v succeeded", last.Name, cronJob.Name)
+	case job.failed:
+		job.report("The last run %v of CronJob %v failed", last.Name, cronJob.Name)
+	default:
+		job.report("The last run %v of CronJob %v has not finished yet", last.Name, cronJob.Name)
+	}
+	return job, nil
+}
+
+// podsStatus reports how many of the pods matched by a selector are ready.
+// Without a workload object the pods are ready once there is at least one and
+// none of them is failing, completed pods such as those of a finished Job
+// included.
+func podsStatus(pods *v1.PodList, selector string) workload {
+	w := workload{selector: selector}
+	summary := summarize(pods)
+	if summary.Completed > 0 {
+		w.report("Pods: %v/%v ready, %v completed", summary.Ready, summary.Total-summary.Completed, summary.Completed)
+	} else {
+		w.report("Pods: %v/%v ready", summary.Ready, summary.Total)
+	}
+	w.ready = summary.Total > 0 && summary.Failing == 0
+	return w
+}
+
+func podReady(pod v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}